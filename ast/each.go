@@ -0,0 +1,192 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// instance is one repetition of a resource or build.
+type instance struct {
+	key   string
+	value Expr
+}
+
+// Expand turns a resource or build with for_each or count into one statement per instance. Instances are
+// named `name[key]` and have every each_key and each_value in them replaced by their key and value, so that
+// adding or removing one key leaves the other instances untouched. The input of a build is passed to its
+// blueprint program as it is, without replacing anything, so values that differ between instances of a build
+// go in its runtime input instead.
+//
+// for_each takes a map, whose keys and values become the instances, or a list of strings, each of which is
+// both the key and the value of an instance. count takes an integer n and declares the instances 0 to n-1
+// with the index as both key and value. Statements without for_each or count are returned as they are.
+func Expand(stmt Stmt) ([]Stmt, error) {
+	switch value := stmt.Value.(type) {
+	case DeclareResource:
+		instances, err := expandInstances(value.ForEach, value.Count)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", value.Name, err)
+		}
+		if instances == nil {
+			return []Stmt{stmt}, nil
+		}
+
+		stmts := make([]Stmt, len(instances))
+		for i, inst := range instances {
+			r := value
+			r.Name = InstanceName(value.Name, inst.key)
+			r.ForEach = Expr{}
+			r.Count = Expr{}
+			r.Exists = inst.substitute(r.Exists)
+			r.Type = inst.substitute(r.Type)
+			r.Provider = inst.substitute(r.Provider)
+			r.Identifier = inst.substitute(r.Identifier)
+			r.Config = inst.substitute(r.Config)
+			stmts[i] = Stmt{Type: stmt.Type, Value: r}
+		}
+
+		return stmts, nil
+	case DeclareBuild:
+		instances, err := expandInstances(value.ForEach, value.Count)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", value.Name, err)
+		}
+		if instances == nil {
+			return []Stmt{stmt}, nil
+		}
+
+		stmts := make([]Stmt, len(instances))
+		for i, inst := range instances {
+			b := value
+			b.Name = InstanceName(value.Name, inst.key)
+			b.ForEach = Expr{}
+			b.Count = Expr{}
+			b.Exists = inst.substitute(b.Exists)
+			b.Runtimeinput = inst.substitute(b.Runtimeinput)
+			stmts[i] = Stmt{Type: stmt.Type, Value: b}
+		}
+
+		return stmts, nil
+	default:
+		return []Stmt{stmt}, nil
+	}
+}
+
+// InstanceName is the name of the instance of a repeated resource or build with the given key.
+func InstanceName(name, key string) string {
+	return name + "[" + key + "]"
+}
+
+// expandInstances returns the instances declared by forEach or count, or nil if neither is set.
+func expandInstances(forEach, count Expr) ([]instance, error) {
+	if !forEach.IsEmpty() && !count.IsEmpty() {
+		return nil, errors.New("must provide only one of for_each and count")
+	}
+
+	if !count.IsEmpty() {
+		n, ok := count.Value.(IntegerLiteral)
+		if !ok {
+			return nil, fmt.Errorf("count must be an integer, got %s", describe(count))
+		}
+		if n.Value < 0 {
+			return nil, fmt.Errorf("count must not be negative, got %d", n.Value)
+		}
+
+		instances := make([]instance, n.Value)
+		for i := range instances {
+			key := strconv.Itoa(i)
+			instances[i] = instance{key: key, value: Expr{Type: "string", Value: StringLiteral{Value: key}}}
+		}
+
+		return instances, nil
+	}
+
+	switch value := forEach.Value.(type) {
+	case nil:
+		return nil, nil
+	case MapCollection:
+		keys := make([]string, 0, len(value.Value))
+		for k := range value.Value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		instances := make([]instance, len(keys))
+		for i, k := range keys {
+			if err := checkKey(k); err != nil {
+				return nil, err
+			}
+
+			instances[i] = instance{key: k, value: value.Value[k]}
+		}
+
+		return instances, nil
+	case ListCollection:
+		seen := map[string]bool{}
+		instances := make([]instance, len(value.Value))
+		for i, elem := range value.Value {
+			s, ok := elem.Value.(StringLiteral)
+			if !ok {
+				return nil, fmt.Errorf("for_each list elements must be strings, got %s at index %d", describe(elem), i)
+			}
+			if err := checkKey(s.Value); err != nil {
+				return nil, err
+			}
+			if seen[s.Value] {
+				return nil, fmt.Errorf("duplicate for_each key %q", s.Value)
+			}
+			seen[s.Value] = true
+
+			instances[i] = instance{key: s.Value, value: elem}
+		}
+
+		return instances, nil
+	default:
+		return nil, fmt.Errorf("for_each must be a map or a list, got %s", describe(forEach))
+	}
+}
+
+// checkKey rejects keys that would make instance IDs ambiguous, since IDs are split on '.', '[' and ']'.
+func checkKey(key string) error {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Errorf("invalid for_each key %q: must not contain '.', '[' or ']'", key)
+	}
+
+	return nil
+}
+
+// substitute replaces each_key and each_value in expr with the key and value of the instance.
+func (inst instance) substitute(expr Expr) Expr {
+	switch value := expr.Value.(type) {
+	case EachKey:
+		return Expr{Type: "string", Value: StringLiteral{Value: inst.key}}
+	case EachValue:
+		return inst.value
+	case MapCollection:
+		m := make(map[string]Expr, len(value.Value))
+		for k, v := range value.Value {
+			m[k] = inst.substitute(v)
+		}
+
+		return Expr{Type: expr.Type, Value: MapCollection{Value: m}}
+	case ListCollection:
+		l := make([]Expr, len(value.Value))
+		for i, v := range value.Value {
+			l[i] = inst.substitute(v)
+		}
+
+		return Expr{Type: expr.Type, Value: ListCollection{Value: l}}
+	case Sensitive:
+		return Expr{Type: expr.Type, Value: Sensitive{Value: inst.substitute(value.Value)}}
+	case Provider:
+		return Expr{Type: expr.Type, Value: Provider{
+			Name:    inst.substitute(value.Name),
+			Version: inst.substitute(value.Version),
+		}}
+	default:
+		return expr
+	}
+}
@@ -0,0 +1,96 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+func list(values ...ast.Expr) ast.Expr {
+	return ast.Expr{Type: "list", Value: ast.ListCollection{Value: values}}
+}
+
+func TestExpand_ForEachMap(t *testing.T) {
+	res := resource("buckets")
+	res.ForEach = mapping(map[string]ast.Expr{
+		"us-east1": str("standard"),
+		"eu-west1": str("nearline"),
+	})
+	res.Identifier = mapping(map[string]ast.Expr{"name": {Type: "each_key", Value: ast.EachKey{}}})
+	res.Config = mapping(map[string]ast.Expr{
+		"class": {Type: "sensitive", Value: ast.Sensitive{Value: ast.Expr{Type: "each_value", Value: ast.EachValue{}}}},
+	})
+
+	stmts, err := ast.Expand(ast.Stmt{Type: "resource", Value: res})
+	require.NoError(t, err)
+
+	expected := func(key, class string) ast.Stmt {
+		r := resource("buckets[" + key + "]")
+		r.Identifier = mapping(map[string]ast.Expr{"name": str(key)})
+		r.Config = mapping(map[string]ast.Expr{
+			"class": {Type: "sensitive", Value: ast.Sensitive{Value: str(class)}},
+		})
+		return ast.Stmt{Type: "resource", Value: r}
+	}
+
+	require.Equal(t, []ast.Stmt{
+		expected("eu-west1", "nearline"),
+		expected("us-east1", "standard"),
+	}, stmts)
+}
+
+func TestExpand_ForEachListAndCount(t *testing.T) {
+	b := build("regions", "region.wasm")
+	b.ForEach = list(str("us-east1"), str("eu-west1"))
+	b.Runtimeinput = mapping(map[string]ast.Expr{"region": {Type: "each_value", Value: ast.EachValue{}}})
+
+	stmts, err := ast.Expand(ast.Stmt{Type: "build", Value: b})
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	require.Equal(t, "regions[us-east1]", stmts[0].Value.(ast.DeclareBuild).Name)
+	require.Equal(t, mapping(map[string]ast.Expr{"region": str("eu-west1")}), stmts[1].Value.(ast.DeclareBuild).Runtimeinput)
+
+	res := resource("replicas")
+	res.Count = ast.Expr{Type: "integer", Value: ast.IntegerLiteral{Value: 2}}
+	res.Identifier = mapping(map[string]ast.Expr{"index": {Type: "each_key", Value: ast.EachKey{}}})
+
+	stmts, err = ast.Expand(ast.Stmt{Type: "resource", Value: res})
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	require.Equal(t, "replicas[1]", stmts[1].Value.(ast.DeclareResource).Name)
+	require.Equal(t, mapping(map[string]ast.Expr{"index": str("1")}), stmts[1].Value.(ast.DeclareResource).Identifier)
+
+	// Statements that aren't repeated are left alone.
+	plain := ast.Stmt{Type: "resource", Value: resource("plain")}
+	stmts, err = ast.Expand(plain)
+	require.NoError(t, err)
+	require.Equal(t, []ast.Stmt{plain}, stmts)
+}
+
+func TestExpand_Errors(t *testing.T) {
+	dup := resource("dup")
+	dup.ForEach = list(str("a"), str("a"))
+	_, err := ast.Expand(ast.Stmt{Type: "resource", Value: dup})
+	require.EqualError(t, err, `dup: duplicate for_each key "a"`)
+
+	both := resource("both")
+	both.ForEach = list(str("a"))
+	both.Count = ast.Expr{Type: "integer", Value: ast.IntegerLiteral{Value: 1}}
+	_, err = ast.Expand(ast.Stmt{Type: "resource", Value: both})
+	require.EqualError(t, err, "both: must provide only one of for_each and count")
+
+	for _, key := range []string{"a.b", "a[b]", "]"} {
+		dotted := build("dotted", "./sub/main.wasm")
+		dotted.ForEach = ast.Expr{Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{key: str("x")}}}
+		_, err = ast.Expand(ast.Stmt{Type: "build", Value: dotted})
+		require.EqualError(t, err, fmt.Sprintf("dotted: invalid for_each key %q: must not contain '.', '[' or ']'", key))
+	}
+
+	listed := resource("listed")
+	listed.ForEach = list(str("ok"), str("us.east"))
+	_, err = ast.Expand(ast.Stmt{Type: "resource", Value: listed})
+	require.EqualError(t, err, `listed: invalid for_each key "us.east": must not contain '.', '[' or ']'`)
+}
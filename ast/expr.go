@@ -1,21 +1,44 @@
 package ast
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 )
 
 type Expr struct {
-	Type  string
-	Value any
+	Type  string `json:"type"`
+	Value any    `json:"value"`
 }
 
 func (e Expr) IsEmpty() bool {
 	return e.Type == "" && e.Value == nil
 }
 
+func (e Expr) MarshalJSON() ([]byte, error) {
+	if e.IsEmpty() {
+		return []byte("null"), nil
+	}
+
+	if valuelessExprTypes[e.Type] {
+		return json.Marshal(struct {
+			Type string `json:"type"`
+		}{Type: e.Type})
+	}
+
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	}{Type: e.Type, Value: e.Value})
+}
+
 func (e *Expr) UnmarshalJSON(data []byte) error {
+	// An expression that was never set is encoded as null.
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
 	var inner struct {
 		Type  string          `json:"type"`
 		Value json.RawMessage `json:"value"`
@@ -82,6 +105,36 @@ func (e *Expr) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		e.Value = *value
+	case "get_data":
+		value := &GetData{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+		e.Value = *value
+	case "get_output":
+		value := &GetOutput{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+		e.Value = *value
+	case "list":
+		value := &ListCollection{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+		e.Value = *value
+	case "null":
+		e.Value = Null{}
+	case "each_key":
+		e.Value = EachKey{}
+	case "each_value":
+		e.Value = EachValue{}
+	case "sensitive":
+		value := &Sensitive{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+		e.Value = *value
 	default:
 		return fmt.Errorf("unsupported expression type: %q", inner.Type)
 	}
@@ -107,6 +160,11 @@ type MapCollection struct {
 	Value map[string]Expr `json:"map_collection"`
 }
 
+// ListCollection is a list of values. It can only be used to drive the for_each of a resource or build.
+type ListCollection struct {
+	Value []Expr `json:"list_collection"`
+}
+
 type Provider struct {
 	Name    Expr `json:"name"`
 	Version Expr `json:"version"`
@@ -116,11 +174,11 @@ type Environment struct {
 }
 
 type LocalFile struct {
-	Path Expr
+	Path Expr `json:"path"`
 }
 
 type LocalFileSource struct {
-	File Expr
+	File Expr `json:"file"`
 }
 
 type GetResource struct {
@@ -129,3 +187,28 @@ type GetResource struct {
 }
 
 type GetEnvironment struct{}
+
+// GetData is the identifier, config and attributes of a data lookup in the same blueprint.
+type GetData struct {
+	Name string `json:"name"`
+}
+
+// GetOutput is the value of an output declared by Build, a build in the same blueprint.
+type GetOutput struct {
+	Build string `json:"build"`
+	Name  string `json:"name"`
+}
+
+// Null explicitly unsets a value, as opposed to leaving it out.
+type Null struct{}
+
+// Sensitive marks the wrapped value as secret. It is never displayed, only compared by hash.
+type Sensitive struct {
+	Value Expr `json:"value"`
+}
+
+// EachKey is the key of the instance being declared by a resource or build with for_each or count.
+type EachKey struct{}
+
+// EachValue is the value of the instance being declared by a resource or build with for_each or count.
+type EachValue struct{}
@@ -56,6 +56,32 @@ func TestExpr_Unmarshal(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "sensitive",
+			in: `{
+			  "type": "sensitive",
+			  "value": {
+			    "value": {"type": "string", "value": {"string_literal": "hunter2"}}
+			  }
+			}`,
+			expected: ast.Expr{
+				Type: "sensitive",
+				Value: ast.Sensitive{
+					Value: ast.Expr{
+						Type:  "string",
+						Value: ast.StringLiteral{Value: "hunter2"},
+					},
+				},
+			},
+		},
+		{
+			name: "null",
+			in:   `{"type": "null"}`,
+			expected: ast.Expr{
+				Type:  "null",
+				Value: ast.Null{},
+			},
+		},
 	}
 
 	for _, test := range tests {
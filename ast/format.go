@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalBlueprint encodes bp in its canonical form: indented, with the keys of every object in sorted order.
+// Two equal blueprints always encode to the same bytes. The blueprint and its inline blueprints are stamped with
+// the version they are encoded in. See encodedVersion.
+func MarshalBlueprint(bp Blueprint) ([]byte, error) {
+	data, err := json.Marshal(stampVersions(bp))
+	if err != nil {
+		return nil, err
+	}
+
+	// Struct fields are encoded in declaration order. Decoding into generic values and encoding again sorts
+	// them along with map keys.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Format parses a blueprint document and returns it in canonical form.
+func Format(data []byte) ([]byte, error) {
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return nil, err
+	}
+
+	return MarshalBlueprint(bp)
+}
+
+// encodedVersion is the version that a blueprint written in version is encoded in. It is FormatVersion, except
+// for blueprints written before RelativeSourcesVersion: those are encoded in the version before it, which reads
+// the same, since migrateV2 leaves documents as they are, but keeps their sources relative to the working
+// directory.
+func encodedVersion(version int) int {
+	if version != 0 && version < RelativeSourcesVersion {
+		return RelativeSourcesVersion - 1
+	}
+
+	return FormatVersion
+}
+
+// stampVersions sets the version of bp and of the inline blueprints it declares to the one they are encoded in.
+func stampVersions(bp Blueprint) Blueprint {
+	bp.Version = encodedVersion(bp.Version)
+
+	stmts := make([]Stmt, len(bp.Stmts))
+	for i, stmt := range bp.Stmts {
+		build, ok := stmt.Value.(DeclareBuild)
+		if !ok || build.BlueprintSource.Inline == nil {
+			stmts[i] = stmt
+			continue
+		}
+
+		inline := stampVersions(*build.BlueprintSource.Inline)
+		build.BlueprintSource.Inline = &inline
+		stmts[i] = Stmt{Type: stmt.Type, Value: build}
+	}
+
+	bp.Stmts = stmts
+	return bp
+}
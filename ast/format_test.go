@@ -0,0 +1,121 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+func TestMarshalBlueprint_RoundTrip(t *testing.T) {
+	res := resource("my-resource")
+	res.Config = mapping(map[string]ast.Expr{
+		"password": {Type: "sensitive", Value: ast.Sensitive{Value: str("hunter2")}},
+		"labels":   {Type: "null", Value: ast.Null{}},
+		"count":    {Type: "integer", Value: ast.IntegerLiteral{Value: 3}},
+		"enabled":  boolean(false),
+	})
+
+	noConfig := resource("no-config")
+	noConfig.Config = ast.Expr{}
+
+	b := build("sub-build", "./sub/main.wasm")
+	b.Input = map[string]any{"region": "us-east1"}
+
+	bp := ast.Blueprint{
+		Version: ast.FormatVersion,
+		Stmts: []ast.Stmt{
+			{Type: "resource", Value: res},
+			{Type: "resource", Value: noConfig},
+			{Type: "build", Value: b},
+		},
+	}
+
+	data, err := ast.MarshalBlueprint(bp)
+	require.NoError(t, err)
+
+	var decoded ast.Blueprint
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, bp, decoded)
+
+	again, err := ast.MarshalBlueprint(decoded)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(again))
+}
+
+func TestMarshalBlueprint_Versions(t *testing.T) {
+	inline := build("inline", "")
+	inline.BlueprintSource = ast.BlueprintSource{Inline: &ast.Blueprint{Stmts: []ast.Stmt{
+		{Type: "build", Value: build("nested", "./nested/main.wasm")},
+	}}}
+	bp := ast.Blueprint{Stmts: []ast.Stmt{{Type: "build", Value: inline}}}
+
+	decode := func(bp ast.Blueprint) ast.Blueprint {
+		data, err := ast.MarshalBlueprint(bp)
+		require.NoError(t, err)
+
+		var decoded ast.Blueprint
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		return decoded
+	}
+
+	// Blueprints built in memory, inline ones included, are encoded in the current version.
+	decoded := decode(bp)
+	require.Equal(t, ast.FormatVersion, decoded.Version)
+	require.Equal(t, ast.FormatVersion, decoded.Stmts[0].Value.(ast.DeclareBuild).BlueprintSource.Inline.Version)
+
+	// Blueprints written before sources were relative to them are encoded in a version that keeps them relative
+	// to the working directory.
+	bp.Version = 1
+	require.Equal(t, ast.RelativeSourcesVersion-1, decode(bp).Version)
+}
+
+func TestFormat(t *testing.T) {
+	// Written before versions were introduced, so its sources stay relative to the working directory.
+	in := `{"stmts":[{"value":{"name":"sub","exists":{"value":{"bool_literal":true},"type":"bool"},` +
+		`"source":{"local_file":{"path":"./sub.wasm"}},"runtime_input":{"type":"map","value":{"map_collection":{}}},"input":null},"type":"build"}]}`
+
+	out, err := ast.Format([]byte(in))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "inputs": null,
+  "stmts": [
+    {
+      "type": "build",
+      "value": {
+        "count": null,
+        "depends_on": null,
+        "exists": {
+          "type": "bool",
+          "value": {
+            "bool_literal": true
+          }
+        },
+        "for_each": null,
+        "input": null,
+        "name": "sub",
+        "runtime_input": {
+          "type": "map",
+          "value": {
+            "map_collection": {}
+          }
+        },
+        "source": {
+          "local_file": {
+            "bundle": "",
+            "path": "./sub.wasm"
+          }
+        }
+      }
+    }
+  ],
+  "version": 2
+}
+`, string(out))
+
+	again, err := ast.Format(out)
+	require.NoError(t, err)
+	require.Equal(t, string(out), string(again))
+}
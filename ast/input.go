@@ -0,0 +1,214 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Input is an input that a blueprint accepts from the builds that declare it, through either their input or
+// their runtime input.
+type Input struct {
+	Name string `json:"name"`
+	// Type is one of InputTypes.
+	Type        string `json:"type"`
+	Default     any    `json:"default"`
+	Required    bool   `json:"required"`
+	Description string `json:"description"`
+}
+
+// InputTypes are the types that an input can be declared with. Values of type any are not checked.
+var InputTypes = []string{"any", "bool", "integer", "list", "map", "string"}
+
+// String describes where the blueprint comes from in errors.
+func (s BlueprintSource) String() string {
+	switch {
+	case s.LocalFile.Path != "":
+		return s.LocalFile.Path
+	case s.Document.Path != "":
+		return s.Document.Path
+	case s.Exec.Command != "":
+		return s.Exec.Command
+	case s.Package.Name != "":
+		return s.Package.String()
+	case s.Inline != nil:
+		return "(inline)"
+	default:
+		return "(none)"
+	}
+}
+
+// CheckInputs checks the input and runtime input that build, declared at path, passes to blueprint against the
+// inputs that blueprint declares. Blueprints that don't declare their inputs accept anything.
+func CheckInputs(path string, build DeclareBuild, blueprint Blueprint) ValidationErrors {
+	if blueprint.Inputs == nil {
+		return nil
+	}
+
+	declared := make(map[string]Input, len(blueprint.Inputs))
+	for _, in := range blueprint.Inputs {
+		declared[in.Name] = in
+	}
+
+	var errs ValidationErrors
+	addError := func(path, format string, args ...any) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	runtimeInput, _ := build.Runtimeinput.Value.(MapCollection)
+	for _, k := range sortedKeys(build.Input) {
+		p := path + ".input." + k
+		in, ok := declared[k]
+		if !ok {
+			addError(p, "blueprint %s has no input %q", build.BlueprintSource, k)
+			continue
+		}
+		if _, ok := runtimeInput.Value[k]; ok {
+			addError(p, "input %q of blueprint %s is also set in runtime_input", k, build.BlueprintSource)
+		}
+
+		if kind := valueKind(build.Input[k]); !inputAccepts(in.Type, kind) {
+			addError(p, "input %q of blueprint %s must be %s, got %s", k, build.BlueprintSource, in.Type, kind)
+		}
+	}
+
+	for _, k := range sortedKeys(runtimeInput.Value) {
+		p := path + ".runtime_input." + k
+		in, ok := declared[k]
+		if !ok {
+			addError(p, "blueprint %s has no input %q", build.BlueprintSource, k)
+			continue
+		}
+
+		if kind, ok := exprKind(runtimeInput.Value[k]); ok && !inputAccepts(in.Type, kind) {
+			addError(p, "input %q of blueprint %s must be %s, got %s", k, build.BlueprintSource, in.Type, kind)
+		}
+	}
+
+	for _, in := range blueprint.Inputs {
+		_, inInput := build.Input[in.Name]
+		_, inRuntimeInput := runtimeInput.Value[in.Name]
+		if in.Required && !inInput && !inRuntimeInput {
+			addError(path, "blueprint %s requires input %q", build.BlueprintSource, in.Name)
+		}
+	}
+
+	return errs
+}
+
+// InterpretBuild interprets the blueprint of build. Inputs that the build leaves out get the defaults that the
+// blueprint declares for them, and the blueprint is interpreted again with them, since its program only sees the
+// input it is run with. The build is returned with its completed input.
+func InterpretBuild(in BlueprintInterpreter, build DeclareBuild) (Blueprint, DeclareBuild, error) {
+	blueprint, err := in.InterpretBlueprint(build.BlueprintSource, build.Input)
+	if err != nil {
+		return Blueprint{}, build, err
+	}
+
+	input, ok := withDefaults(build, blueprint)
+	if !ok {
+		return blueprint, build, nil
+	}
+
+	build.Input = input
+	blueprint, err = in.InterpretBlueprint(build.BlueprintSource, build.Input)
+	if err != nil {
+		return Blueprint{}, build, err
+	}
+
+	return blueprint, build, nil
+}
+
+// withDefaults returns the input of build with the defaults of the inputs it leaves out, and whether there were
+// any. Required inputs can't have defaults, so theirs are ignored.
+func withDefaults(build DeclareBuild, blueprint Blueprint) (map[string]any, bool) {
+	runtimeInput, _ := build.Runtimeinput.Value.(MapCollection)
+
+	var input map[string]any
+	for _, in := range blueprint.Inputs {
+		if in.Default == nil || in.Required {
+			continue
+		}
+		if _, ok := build.Input[in.Name]; ok {
+			continue
+		}
+		if _, ok := runtimeInput.Value[in.Name]; ok {
+			continue
+		}
+
+		// The input of the build is shared by its instances, so it is copied rather than changed.
+		if input == nil {
+			input = make(map[string]any, len(build.Input)+1)
+			for k, v := range build.Input {
+				input[k] = v
+			}
+		}
+		input[in.Name] = in.Default
+	}
+
+	return input, input != nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// inputAccepts reports whether an input of type t accepts a value of kind. Null unsets any input.
+func inputAccepts(t, kind string) bool {
+	return t == "any" || kind == "null" || t == kind
+}
+
+// valueKind is the input type of a decoded JSON value.
+func valueKind(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+
+		return "number"
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// exprKind is the input type of a literal expression. The type of other expressions is only known once they
+// are evaluated.
+func exprKind(expr Expr) (string, bool) {
+	switch value := expr.Value.(type) {
+	case Null:
+		return "null", true
+	case StringLiteral:
+		return "string", true
+	case BoolLiteral:
+		return "bool", true
+	case IntegerLiteral:
+		return "integer", true
+	case MapCollection:
+		return "map", true
+	case ListCollection:
+		return "list", true
+	case Sensitive:
+		return exprKind(value.Value)
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,124 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+func TestCheckInputs(t *testing.T) {
+	blueprint := ast.Blueprint{Inputs: []ast.Input{
+		{Name: "region", Type: "string", Required: true},
+		{Name: "replicas", Type: "integer", Default: float64(1)},
+		{Name: "labels", Type: "map"},
+		{Name: "extra", Type: "any"},
+		{Name: "zones", Type: "list"},
+	}}
+
+	b := build("sub", "sub.wasm")
+	b.Input = map[string]any{"replicas": "two", "regoin": "us-east1", "extra": []any{1}, "zones": "a"}
+	b.Runtimeinput = mapping(map[string]ast.Expr{
+		"labels":   str("env=prod"),
+		"replicas": {Type: "integer", Value: ast.IntegerLiteral{Value: 2}},
+		// References are only checked once they are evaluated.
+		"extra": {Type: "get_output", Value: ast.GetOutput{Build: "other", Name: "extra"}},
+	})
+
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.sub.input.extra", Message: `input "extra" of blueprint sub.wasm is also set in runtime_input`},
+		{Path: ".Build.sub.input.regoin", Message: `blueprint sub.wasm has no input "regoin"`},
+		{Path: ".Build.sub.input.replicas", Message: `input "replicas" of blueprint sub.wasm is also set in runtime_input`},
+		{Path: ".Build.sub.input.replicas", Message: `input "replicas" of blueprint sub.wasm must be integer, got string`},
+		{Path: ".Build.sub.input.zones", Message: `input "zones" of blueprint sub.wasm must be list, got string`},
+		{Path: ".Build.sub.runtime_input.labels", Message: `input "labels" of blueprint sub.wasm must be map, got string`},
+		{Path: ".Build.sub", Message: `blueprint sub.wasm requires input "region"`},
+	}, ast.CheckInputs(".Build.sub", b, blueprint))
+
+	b.Input = map[string]any{"region": "us-east1", "zones": []any{"a", "b"}}
+	b.Runtimeinput = mapping(map[string]ast.Expr{"replicas": {Type: "null", Value: ast.Null{}}})
+	require.Empty(t, ast.CheckInputs(".Build.sub", b, blueprint))
+
+	// Blueprints that don't declare their inputs accept anything.
+	b.Input = map[string]any{"anything": true}
+	require.Empty(t, ast.CheckInputs(".Build.sub", b, ast.Blueprint{}))
+	require.NotEmpty(t, ast.CheckInputs(".Build.sub", b, ast.Blueprint{Inputs: []ast.Input{}}))
+}
+
+// echoInterpreter declares inputs with defaults and outputs the input it is run with.
+type echoInterpreter struct {
+	inputs []map[string]any
+}
+
+func (e *echoInterpreter) InterpretBlueprint(_ ast.BlueprintSource, input map[string]any) (ast.Blueprint, error) {
+	e.inputs = append(e.inputs, input)
+
+	var stmts []ast.Stmt
+	for _, k := range []string{"region", "replicas"} {
+		if v, ok := input[k].(string); ok {
+			stmts = append(stmts, ast.Stmt{Type: "output", Value: ast.DeclareOutput{Name: k, Value: str(v)}})
+		}
+	}
+
+	return ast.Blueprint{
+		Inputs: []ast.Input{
+			{Name: "region", Type: "string", Default: "us-east1"},
+			{Name: "replicas", Type: "string", Default: "1"},
+			{Name: "zone", Type: "string", Default: "a"},
+		},
+		Stmts: stmts,
+	}, nil
+}
+
+func TestInterpretBuild_Defaults(t *testing.T) {
+	in := &echoInterpreter{}
+	b := build("sub", "sub.wasm")
+	b.Input = map[string]any{"region": "eu-west1"}
+	b.Runtimeinput = mapping(map[string]ast.Expr{"zone": str("b")})
+
+	blueprint, completed, err := ast.InterpretBuild(in, b)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"region": "eu-west1", "replicas": "1"}, completed.Input)
+	require.Equal(t, []ast.Stmt{
+		{Type: "output", Value: ast.DeclareOutput{Name: "region", Value: str("eu-west1")}},
+		{Type: "output", Value: ast.DeclareOutput{Name: "replicas", Value: str("1")}},
+	}, blueprint.Stmts)
+
+	// The input of the build is left as it is, since instances share it.
+	require.Equal(t, map[string]any{"region": "eu-west1"}, b.Input)
+
+	// Builds that set every input are only interpreted once.
+	in = &echoInterpreter{}
+	b.Input = map[string]any{"region": "eu-west1", "replicas": "3"}
+	_, _, err = ast.InterpretBuild(in, b)
+	require.NoError(t, err)
+	require.Len(t, in.inputs, 1)
+}
+
+func TestValidator_Inputs(t *testing.T) {
+	sub := build("sub", "sub.wasm")
+	sub.Input = map[string]any{"zone": "a"}
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{{Type: "build", Value: sub}}},
+		"sub.wasm": {Inputs: []ast.Input{
+			{Name: "region", Type: "string", Required: true, Default: "us-east1"},
+			{Name: "replicas", Type: "number"},
+			{Name: "zone", Type: "bool", Default: "a"},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.sub.inputs[0].default", Message: `required input "region" can't have a default`},
+		{Path: ".Build.sub.inputs[1].type", Message: `unsupported input type "number": must be one of any, bool, integer, list, map, string`},
+		{Path: ".Build.sub.inputs[2].default", Message: `default of input "zone" must be bool, got string`},
+		{Path: ".Build.sub.input.zone", Message: `input "zone" of blueprint sub.wasm must be bool, got string`},
+		{Path: ".Build.sub", Message: `blueprint sub.wasm requires input "region"`},
+	}, errs)
+}
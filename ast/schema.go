@@ -0,0 +1,196 @@
+package ast
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:embed blueprint.schema.json
+var schema []byte
+
+// Schema returns the published JSON Schema of the blueprint format.
+func Schema() []byte {
+	return schema
+}
+
+// ExprTypes maps every expression type to the Go type of its value. It must list the same types as
+// Expr.UnmarshalJSON.
+var ExprTypes = map[string]any{
+	"string":          StringLiteral{},
+	"bool":            BoolLiteral{},
+	"integer":         IntegerLiteral{},
+	"map":             MapCollection{},
+	"list":            ListCollection{},
+	"provider":        Provider{},
+	"environment":     Environment{},
+	"local_file":      LocalFile{},
+	"get_environment": GetEnvironment{},
+	"get_resource":    GetResource{},
+	"get_output":      GetOutput{},
+	"get_data":        GetData{},
+	"null":            Null{},
+	"sensitive":       Sensitive{},
+	"each_key":        EachKey{},
+	"each_value":      EachValue{},
+}
+
+// StmtTypes maps every statement type to the Go type of its value. It must list the same types as
+// Stmt.UnmarshalJSON.
+var StmtTypes = map[string]any{
+	"resource": DeclareResource{},
+	"build":    DeclareBuild{},
+	"output":   DeclareOutput{},
+	"data":     DeclareData{},
+	"moved":    DeclareMoved{},
+}
+
+// valuelessExprTypes are expressions that carry no value.
+var valuelessExprTypes = map[string]bool{
+	"null":       true,
+	"each_key":   true,
+	"each_value": true,
+}
+
+var (
+	exprType = reflect.TypeOf(Expr{})
+	stmtType = reflect.TypeOf(Stmt{})
+)
+
+// GenerateSchema builds the JSON Schema of the blueprint format from the Go types in this package.
+func GenerateSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: map[string]any{}}
+
+	g.defs["Blueprint"] = g.structSchema(reflect.TypeOf(Blueprint{}))
+	g.defs["Stmt"] = g.unionSchema(StmtTypes, nil)
+
+	expr := g.unionSchema(ExprTypes, valuelessExprTypes)
+	// Expressions that were never set are encoded as null.
+	expr["oneOf"] = append(expr["oneOf"].([]any), map[string]any{"type": "null"})
+	g.defs["Expr"] = expr
+
+	doc := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     fmt.Sprintf("urn:athanor:blueprint:v%d", FormatVersion),
+		"title":   fmt.Sprintf("Athanor blueprint v%d", FormatVersion),
+		"$ref":    "#/$defs/Blueprint",
+		"$defs":   g.defs,
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+// unionSchema describes a {"type": ..., "value": ...} object whose value depends on its type.
+func (g *schemaGenerator) unionSchema(types map[string]any, valueless map[string]bool) map[string]any {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variants := make([]any, 0, len(names))
+	for _, name := range names {
+		variant := map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"type"},
+			"properties": map[string]any{
+				"type": map[string]any{"const": name},
+			},
+		}
+
+		if !valueless[name] {
+			variant["required"] = []string{"type", "value"}
+			variant["properties"].(map[string]any)["value"] = g.typeSchema(reflect.TypeOf(types[name]))
+		}
+
+		variants = append(variants, variant)
+	}
+
+	return map[string]any{"oneOf": variants}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	switch t {
+	case exprType:
+		return ref("Expr")
+	case stmtType:
+		return ref("Stmt")
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Slice:
+		// Go encodes nil slices and maps as null.
+		return map[string]any{"type": []string{"array", "null"}, "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{map[string]any{"type": "null"}, g.typeSchema(t.Elem())}}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Reserve the name first so that recursive types terminate.
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+
+		return ref(t.Name())
+	default:
+		panic(fmt.Sprintf("unsupported type in blueprint schema: %s", t))
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		props[name] = g.typeSchema(f.Type)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           props,
+	}
+}
@@ -0,0 +1,127 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	athanor_ast "github.com/alchematik/athanor/ast"
+)
+
+var update = flag.Bool("update", false, "regenerate the published blueprint schema")
+
+func TestSchema(t *testing.T) {
+	generated, err := athanor_ast.GenerateSchema()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.WriteFile("blueprint.schema.json", generated, 0o644))
+		return
+	}
+
+	require.Equal(t, string(generated), string(athanor_ast.Schema()), "blueprint.schema.json is out of date, run `make schema`")
+}
+
+func TestSchema_TypesMatchUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		receiver string
+		types    map[string]any
+		decode   func([]byte) (any, error)
+	}{
+		{
+			name:     "expr",
+			file:     "expr.go",
+			receiver: "Expr",
+			types:    athanor_ast.ExprTypes,
+			decode: func(data []byte) (any, error) {
+				var e athanor_ast.Expr
+				err := json.Unmarshal(data, &e)
+				return e.Value, err
+			},
+		},
+		{
+			name:     "stmt",
+			file:     "stmt.go",
+			receiver: "Stmt",
+			types:    athanor_ast.StmtTypes,
+			decode: func(data []byte) (any, error) {
+				var s athanor_ast.Stmt
+				err := json.Unmarshal(data, &s)
+				return s.Value, err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var registered []string
+			for name := range test.types {
+				registered = append(registered, name)
+			}
+			sort.Strings(registered)
+			require.Equal(t, registered, unmarshalCases(t, test.file, test.receiver))
+
+			for name, value := range test.types {
+				decoded, err := test.decode([]byte(fmt.Sprintf(`{"type": %q, "value": {}}`, name)))
+				require.NoError(t, err, name)
+				require.Equal(t, reflect.TypeOf(value), reflect.TypeOf(decoded), name)
+			}
+		})
+	}
+}
+
+// unmarshalCases returns the types handled by the switch in the UnmarshalJSON method of receiver.
+func unmarshalCases(t *testing.T, file, receiver string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	require.NoError(t, err)
+
+	var cases []string
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "UnmarshalJSON" || fn.Recv == nil {
+			continue
+		}
+
+		star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+		if !ok || star.X.(*ast.Ident).Name != receiver {
+			continue
+		}
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			clause, ok := n.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+
+			for _, e := range clause.List {
+				lit, ok := e.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+
+				v, err := strconv.Unquote(lit.Value)
+				require.NoError(t, err)
+				if v != "" {
+					cases = append(cases, v)
+				}
+			}
+
+			return true
+		})
+	}
+
+	sort.Strings(cases)
+	return cases
+}
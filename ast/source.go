@@ -0,0 +1,117 @@
+package ast
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Dir is the directory that the sources of the builds in the blueprint are relative to. It is the directory of
+// the blueprint's file, or of the command of an exec source if the command is a path, or else the directory the
+// command runs in. Inline blueprints have no directory of their own and use the one of the blueprint that declares
+// them, so Dir returns parent for them.
+func (s BlueprintSource) Dir(parent string) string {
+	switch {
+	case s.LocalFile.Path != "":
+		return filepath.Dir(s.LocalFile.Path)
+	case s.Document.Path != "":
+		return filepath.Dir(s.Document.Path)
+	case isPath(s.Exec.Command):
+		return filepath.Dir(s.Exec.Command)
+	case s.Exec.Dir != "":
+		return s.Exec.Dir
+	case s.Exec.Command != "":
+		return "."
+	default:
+		return parent
+	}
+}
+
+// Resolve makes the relative paths in the source relative to dir, the directory of the blueprint that declares
+// it, instead. Absolute paths and commands that are looked up in PATH are left as they are. Exec programs run in
+// dir, unless they set a directory of their own.
+func (s BlueprintSource) Resolve(dir string) BlueprintSource {
+	s.LocalFile.Path = resolvePath(dir, s.LocalFile.Path)
+	s.LocalFile.Bundle = resolvePath(dir, s.LocalFile.Bundle)
+	s.Document.Path = resolvePath(dir, s.Document.Path)
+	if isPath(s.Exec.Command) {
+		s.Exec.Command = resolvePath(dir, s.Exec.Command)
+	}
+	if s.Exec.Command != "" {
+		s.Exec.Dir = resolvePath(dir, s.Exec.Dir)
+		if s.Exec.Dir == "" {
+			s.Exec.Dir = dir
+		}
+	}
+
+	return s
+}
+
+// RelativeSourcesVersion is the first format version whose build sources are relative to the blueprint that
+// declares them. Blueprints written in older versions have sources relative to the working directory.
+const RelativeSourcesVersion = 3
+
+// ResolveSources resolves the sources of the builds in blueprint, and in the inline blueprints it declares,
+// relative to dir. Blueprints written before RelativeSourcesVersion are left as they are.
+func ResolveSources(blueprint Blueprint, dir string) Blueprint {
+	if blueprint.Version != 0 && blueprint.Version < RelativeSourcesVersion {
+		return blueprint
+	}
+
+	stmts := make([]Stmt, len(blueprint.Stmts))
+	for i, stmt := range blueprint.Stmts {
+		build, ok := stmt.Value.(DeclareBuild)
+		if !ok {
+			stmts[i] = stmt
+			continue
+		}
+
+		build.BlueprintSource = build.BlueprintSource.Resolve(dir)
+		if inline := build.BlueprintSource.Inline; inline != nil {
+			resolved := ResolveSources(*inline, dir)
+			build.BlueprintSource.Inline = &resolved
+		}
+
+		stmts[i] = Stmt{Type: stmt.Type, Value: build}
+	}
+
+	blueprint.Stmts = stmts
+	return blueprint
+}
+
+// Sandbox marks the sources of the builds in blueprint, and in the inline blueprints it declares, as sandboxed.
+// Interpreters mark the blueprints that sandboxed sources lead to in turn, so that everything below a sandboxed
+// blueprint is sandboxed too.
+func Sandbox(blueprint Blueprint) Blueprint {
+	stmts := make([]Stmt, len(blueprint.Stmts))
+	for i, stmt := range blueprint.Stmts {
+		build, ok := stmt.Value.(DeclareBuild)
+		if !ok {
+			stmts[i] = stmt
+			continue
+		}
+
+		build.BlueprintSource.Sandboxed = true
+		if inline := build.BlueprintSource.Inline; inline != nil {
+			sandboxed := Sandbox(*inline)
+			build.BlueprintSource.Inline = &sandboxed
+		}
+
+		stmts[i] = Stmt{Type: stmt.Type, Value: build}
+	}
+
+	blueprint.Stmts = stmts
+	return blueprint
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// isPath reports whether command is a path rather than the name of a program in PATH.
+func isPath(command string) bool {
+	return strings.ContainsRune(command, '/') || strings.ContainsRune(command, filepath.Separator)
+}
@@ -0,0 +1,114 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+func TestResolveSources(t *testing.T) {
+	inline := ast.Blueprint{Stmts: []ast.Stmt{
+		{Type: "build", Value: build("nested", "./nested/main.wasm")},
+	}}
+	exec := ast.BlueprintSource{Exec: ast.BlueprintSourceExec{Command: "./gen.sh", Args: []string{"config.jsonnet"}}}
+
+	bundled := build("sub", "./sub/main.wasm")
+	bundled.BlueprintSource.LocalFile.Bundle = "data"
+
+	blueprint := ast.Blueprint{Stmts: []ast.Stmt{
+		{Type: "build", Value: bundled},
+		{Type: "build", Value: build("doc", "../shared/blueprint.yaml")},
+		{Type: "build", Value: build("abs", "/opt/blueprints/main.wasm")},
+		{Type: "build", Value: ast.DeclareBuild{Name: "exec", BlueprintSource: exec}},
+		{Type: "build", Value: ast.DeclareBuild{Name: "python", BlueprintSource: ast.BlueprintSource{
+			Exec: ast.BlueprintSourceExec{Command: "python3", Args: []string{"gen.py"}},
+		}}},
+		{Type: "build", Value: ast.DeclareBuild{Name: "inline", BlueprintSource: ast.BlueprintSource{Inline: &inline}}},
+		{Type: "resource", Value: resource("bucket")},
+	}}
+
+	source := func(stmt ast.Stmt) ast.BlueprintSource {
+		return stmt.Value.(ast.DeclareBuild).BlueprintSource
+	}
+
+	resolved := ast.ResolveSources(blueprint, "example/gcp")
+	require.Equal(t, "example/gcp/sub/main.wasm", source(resolved.Stmts[0]).LocalFile.Path)
+	require.Equal(t, "example/gcp/data", source(resolved.Stmts[0]).LocalFile.Bundle)
+	require.Equal(t, "example/shared/blueprint.yaml", source(resolved.Stmts[1]).LocalFile.Path)
+	require.Equal(t, "/opt/blueprints/main.wasm", source(resolved.Stmts[2]).LocalFile.Path)
+	// The command is resolved like the other paths, while it runs in the directory of the blueprint.
+	require.Equal(t, ast.BlueprintSourceExec{Command: "example/gcp/gen.sh", Args: []string{"config.jsonnet"}, Dir: "example/gcp"}, source(resolved.Stmts[3]).Exec)
+	require.Equal(t, ast.BlueprintSourceExec{Command: "python3", Args: []string{"gen.py"}, Dir: "example/gcp"}, source(resolved.Stmts[4]).Exec)
+	require.Equal(t, "example/gcp/nested/main.wasm", source(source(resolved.Stmts[5]).Inline.Stmts[0]).LocalFile.Path)
+	require.Equal(t, blueprint.Stmts[6], resolved.Stmts[6])
+
+	// The blueprint that was resolved is left untouched.
+	require.Equal(t, "./sub/main.wasm", source(blueprint.Stmts[0]).LocalFile.Path)
+	require.Equal(t, "./nested/main.wasm", source(inline.Stmts[0]).LocalFile.Path)
+}
+
+func TestResolveSources_Version(t *testing.T) {
+	blueprint := ast.Blueprint{Stmts: []ast.Stmt{
+		{Type: "build", Value: build("sub", "./example/gcp/sub/main.wasm")},
+	}}
+
+	// Blueprints written before sources were relative to them used paths relative to the working directory.
+	for _, version := range []int{1, 2} {
+		blueprint.Version = version
+		require.Equal(t, blueprint, ast.ResolveSources(blueprint, "example/gcp"))
+	}
+
+	blueprint.Version = ast.RelativeSourcesVersion
+	resolved := ast.ResolveSources(blueprint, "example/gcp")
+	require.Equal(t, "example/gcp/example/gcp/sub/main.wasm", resolved.Stmts[0].Value.(ast.DeclareBuild).BlueprintSource.LocalFile.Path)
+}
+
+func TestBlueprint_KeepsWrittenVersion(t *testing.T) {
+	var bp ast.Blueprint
+	require.NoError(t, json.Unmarshal([]byte(`{"version": 2, "stmts": [
+	  {"type": "build", "value": {"name": "sub", "source": {"inline": {"stmts": []}}}}
+	]}`), &bp))
+	require.Equal(t, 2, bp.Version)
+	require.Equal(t, 2, bp.Stmts[0].Value.(ast.DeclareBuild).BlueprintSource.Inline.Version)
+}
+
+func TestSandbox(t *testing.T) {
+	inline := ast.Blueprint{Stmts: []ast.Stmt{{Type: "build", Value: build("nested", "./nested/main.wasm")}}}
+	blueprint := ast.Blueprint{Stmts: []ast.Stmt{
+		{Type: "build", Value: build("doc", "./blueprint.json")},
+		{Type: "build", Value: ast.DeclareBuild{Name: "inline", BlueprintSource: ast.BlueprintSource{Inline: &inline}}},
+		{Type: "resource", Value: resource("bucket")},
+	}}
+
+	source := func(stmt ast.Stmt) ast.BlueprintSource {
+		return stmt.Value.(ast.DeclareBuild).BlueprintSource
+	}
+
+	sandboxed := ast.Sandbox(blueprint)
+	require.True(t, source(sandboxed.Stmts[0]).Sandboxed)
+	require.True(t, source(sandboxed.Stmts[1]).Sandboxed)
+	require.True(t, source(source(sandboxed.Stmts[1]).Inline.Stmts[0]).Sandboxed)
+	require.Equal(t, blueprint.Stmts[2], sandboxed.Stmts[2])
+
+	// The flag isn't encoded. Only Sandbox sets it, after blueprints are decoded.
+	data, err := json.Marshal(source(sandboxed.Stmts[0]))
+	require.NoError(t, err)
+	var decoded ast.BlueprintSource
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.False(t, decoded.Sandboxed)
+
+	// The blueprint that was sandboxed is left untouched.
+	require.False(t, source(inline.Stmts[0]).Sandboxed)
+}
+
+func TestBlueprintSource_Dir(t *testing.T) {
+	require.Equal(t, "example/gcp", ast.SourceFromPath("example/gcp/main.wasm").Dir("."))
+	require.Equal(t, "example", ast.SourceFromPath("example/blueprint.json").Dir("."))
+	require.Equal(t, "scripts", ast.BlueprintSource{Exec: ast.BlueprintSourceExec{Command: "scripts/gen.sh"}}.Dir("."))
+	require.Equal(t, ".", ast.BlueprintSource{Exec: ast.BlueprintSourceExec{Command: "jsonnet"}}.Dir("example"))
+	require.Equal(t, "config", ast.BlueprintSource{Exec: ast.BlueprintSourceExec{Command: "jsonnet", Dir: "config"}}.Dir("example"))
+	require.Equal(t, "example", ast.BlueprintSource{Inline: &ast.Blueprint{}}.Dir("example"))
+}
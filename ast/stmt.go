@@ -4,10 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 )
 
 type Blueprint struct {
-	Stmts []Stmt `json:"stmts"`
+	// Version is the format version the blueprint was written in. Blueprints are migrated to FormatVersion
+	// when they are decoded, but keep the version they were written in, since it decides how their sources are
+	// resolved. Blueprints built in memory have version 0.
+	Version int    `json:"version"`
+	Stmts   []Stmt `json:"stmts"`
+	// Inputs are the inputs the blueprint accepts. Builds of blueprints that leave them out can pass anything.
+	Inputs []Input `json:"inputs"`
 }
 
 type Stmt struct {
@@ -15,6 +22,13 @@ type Stmt struct {
 	Value any    `json:"value"`
 }
 
+func (s Stmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	}{Type: s.Type, Value: s.Value})
+}
+
 func (s *Stmt) UnmarshalJSON(data []byte) error {
 	var inner struct {
 		Type  string          `json:"type"`
@@ -41,6 +55,27 @@ func (s *Stmt) UnmarshalJSON(data []byte) error {
 			return err
 		}
 
+		s.Value = *value
+	case "data":
+		value := &DeclareData{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+
+		s.Value = *value
+	case "output":
+		value := &DeclareOutput{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+
+		s.Value = *value
+	case "moved":
+		value := &DeclareMoved{}
+		if err := json.Unmarshal(inner.Value, &value); err != nil {
+			return err
+		}
+
 		s.Value = *value
 	default:
 		return fmt.Errorf("unsupported statement type: %q", inner.Type)
@@ -58,20 +93,169 @@ type DeclareResource struct {
 	Provider   Expr   `json:"provider"`
 	Identifier Expr   `json:"identifier"`
 	Config     Expr   `json:"config"`
+	// ForEach and Count repeat the resource. See Expand.
+	ForEach   Expr      `json:"for_each"`
+	Count     Expr      `json:"count"`
+	Lifecycle Lifecycle `json:"lifecycle"`
+	// DependsOn are the addresses of resources and builds that must be evaluated first. An address is the
+	// name of a resource or build in the same blueprint, optionally followed by the names of components inside
+	// a build, such as `network.vpc`. Addressing a repeated resource or build depends on all of its instances.
+	DependsOn []string `json:"depends_on"`
+}
+
+// Lifecycle controls how changes to a resource are carried out.
+type Lifecycle struct {
+	// PreventDestroy fails any diff that would delete or replace the resource.
+	PreventDestroy bool `json:"prevent_destroy"`
+	// IgnoreChanges are dot separated paths into the config, such as `labels.env`, whose changes are never
+	// applied.
+	IgnoreChanges []string `json:"ignore_changes"`
+	// CreateBeforeDestroy creates the replacement of the resource before deleting it.
+	CreateBeforeDestroy bool `json:"create_before_destroy"`
 }
 
 type DeclareBuild struct {
-	Name            string          `json:"name"`
-	Exists          Expr            `json:"exists"`
+	Name   string `json:"name"`
+	Exists Expr   `json:"exists"`
+	// Input is passed to the blueprint program as it is. It isn't an expression, so each_key and each_value
+	// can't be used in it.
 	Input           map[string]any  `json:"input"`
 	Runtimeinput    Expr            `json:"runtime_input"`
 	BlueprintSource BlueprintSource `json:"source"`
+	// ForEach and Count repeat the build. See Expand.
+	ForEach Expr `json:"for_each"`
+	Count   Expr `json:"count"`
+	// DependsOn are the addresses of resources and builds that must be evaluated first.
+	DependsOn []string `json:"depends_on"`
 }
 
+// DeclareData reads an existing object through its provider without ever managing it. The object must exist.
+type DeclareData struct {
+	Name       string `json:"name"`
+	Type       Expr   `json:"type"`
+	Provider   Expr   `json:"provider"`
+	Identifier Expr   `json:"identifier"`
+}
+
+// DeclareOutput exposes a value computed inside a build to the blueprint that declares the build.
+type DeclareOutput struct {
+	Name  string `json:"name"`
+	Value Expr   `json:"value"`
+}
+
+// DeclareMoved records that the resource or build at the address From is now at the address To, so that renaming
+// it or moving it into a build carries its state over instead of deleting and recreating it. Addresses are
+// relative to the blueprint, like those of DependsOn.
+type DeclareMoved struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BlueprintSource is where the blueprint of a build comes from. Exactly one kind of source should be set.
 type BlueprintSource struct {
 	LocalFile BlueprintSourceLocalFile `json:"local_file"`
+	Document  BlueprintSourceDocument  `json:"document"`
+	Exec      BlueprintSourceExec      `json:"exec"`
+	Package   BlueprintSourcePackage   `json:"package"`
+	Inline    *Blueprint               `json:"inline"`
+
+	// Sandboxed is set by Sandbox on the sources of builds below a WebAssembly blueprint, however deep, so that
+	// exec sources there can be refused. It isn't encoded, so that blueprints can't clear it.
+	Sandboxed bool `json:"-"`
 }
 
+// Kinds returns the kinds of source that are set.
+func (s BlueprintSource) Kinds() []string {
+	var kinds []string
+	if s.LocalFile.Path != "" {
+		kinds = append(kinds, "local_file")
+	}
+	if s.Document.Path != "" {
+		kinds = append(kinds, "document")
+	}
+	if s.Exec.Command != "" {
+		kinds = append(kinds, "exec")
+	}
+	if s.Package.Name != "" {
+		kinds = append(kinds, "package")
+	}
+	if s.Inline != nil {
+		kinds = append(kinds, "inline")
+	}
+
+	return kinds
+}
+
+// SourceFromPath returns a document source for JSON and YAML files and a WebAssembly source for anything else.
+func SourceFromPath(path string) BlueprintSource {
+	switch filepath.Ext(path) {
+	case ".json", ".yaml", ".yml":
+		return BlueprintSource{Document: BlueprintSourceDocument{Path: path}}
+	default:
+		return BlueprintSource{LocalFile: BlueprintSourceLocalFile{Path: path}}
+	}
+}
+
+// MarshalJSON only encodes the kinds of source that are set.
+func (s BlueprintSource) MarshalJSON() ([]byte, error) {
+	out := map[string]any{}
+	if s.LocalFile.Path != "" {
+		out["local_file"] = s.LocalFile
+	}
+	if s.Document.Path != "" {
+		out["document"] = s.Document
+	}
+	if s.Exec.Command != "" {
+		out["exec"] = s.Exec
+	}
+	if s.Package.Name != "" {
+		out["package"] = s.Package
+	}
+	if s.Inline != nil {
+		out["inline"] = s.Inline
+	}
+
+	return json.Marshal(out)
+}
+
+// BlueprintSourceLocalFile is a WebAssembly program that writes the blueprint.
 type BlueprintSourceLocalFile struct {
 	Path string `json:"path"`
+	// Bundle is a directory of data files, such as templates and config tables, that the program can read at
+	// /bundle. It is optional, and relative to the blueprint that declares the build, like Path.
+	Bundle string `json:"bundle"`
+}
+
+// BlueprintSourceDocument is a plain JSON or YAML blueprint. The format is picked by the file extension.
+type BlueprintSourceDocument struct {
+	Path string `json:"path"`
+}
+
+// BlueprintSourceExec is a program that runs on the host, outside of any sandbox. It is given the input of the
+// build as a JSON object on stdin and writes the blueprint as JSON to stdout.
+type BlueprintSourceExec struct {
+	// Command is a program on the PATH, or a path to one. Once resolved, a path is relative to the working
+	// directory, like other sources, not to Dir.
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Dir is the directory that the program runs in. Resolve sets it to the directory of the blueprint that
+	// declares the build, so that relative arguments work like relative sources.
+	Dir string `json:"dir,omitempty"`
+}
+
+// BlueprintSourcePackage is a version of a blueprint package: an archive of a WebAssembly program, a manifest
+// and bundled files. Without a version, the version in the lock file or else the newest one is used. Checksum,
+// if set, is the "sha256:<hex>" digest that the archive must have.
+type BlueprintSourcePackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+func (p BlueprintSourcePackage) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+
+	return p.Name + "@" + p.Version
 }
@@ -0,0 +1,473 @@
+package ast
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// BlueprintInterpreter loads the blueprint that a build points at.
+type BlueprintInterpreter interface {
+	InterpretBlueprint(source BlueprintSource, input map[string]any) (Blueprint, error)
+}
+
+// ValidationError is a problem found at Path, a statement path such as `.Build.sub-build.my-resource.config`.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Validator checks a build and every blueprint it pulls in, collecting all problems instead of stopping at
+// the first one.
+type Validator struct {
+	BlueprintInterpreter BlueprintInterpreter
+
+	errs ValidationErrors
+
+	// refs are the references made by the blueprint being validated. They are checked once all of its
+	// statements have been seen.
+	refs []reference
+
+	// repeated is set while validating a resource or build with for_each or count, the only places where
+	// each_key and each_value can be used.
+	repeated bool
+}
+
+type reference struct {
+	path string
+	// kind is the statement type that is referenced.
+	kind  string
+	build string
+	name  string
+}
+
+func (v *Validator) Validate(parentPath string, build DeclareBuild) error {
+	v.errs = nil
+	v.validateBuild(parentPath, build)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+func (v *Validator) addError(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateStmts checks the statements of a blueprint and returns the names of the outputs it declares.
+func (v *Validator) validateStmts(parentPath string, stmts []Stmt) map[string]bool {
+	outer, outerRepeated := v.refs, v.repeated
+	v.refs, v.repeated = nil, false
+	defer func() { v.refs, v.repeated = outer, outerRepeated }()
+
+	names := map[string]bool{}
+	outputs := map[string]bool{}
+	resources := map[string]bool{}
+	data := map[string]bool{}
+	builds := map[string]map[string]bool{}
+	// components are the names of the resources and builds, before they are repeated.
+	components := map[string]bool{}
+	var moves []DeclareMoved
+	for i, stmt := range stmts {
+		switch value := stmt.Value.(type) {
+		case DeclareOutput:
+			if outputs[value.Name] {
+				v.addError(parentPath+"."+value.Name, "duplicate output %q in %s", value.Name, parentPath)
+			}
+			outputs[value.Name] = true
+
+			v.validateOutput(parentPath, value)
+			continue
+		case DeclareMoved:
+			moves = append(moves, value)
+			continue
+		case DeclareResource:
+			for _, name := range instanceNames(stmt) {
+				resources[name] = true
+			}
+			components[value.Name] = true
+		case DeclareData:
+			data[value.Name] = true
+		case DeclareBuild:
+			components[value.Name] = true
+		default:
+			v.addError(fmt.Sprintf("%s[%d]", parentPath, i), "unsupported statement type: %q", stmt.Type)
+			continue
+		}
+
+		name := stmtName(stmt)
+		if names[name] {
+			v.addError(parentPath+"."+name, "duplicate name %q in %s", name, parentPath)
+		}
+		names[name] = true
+
+		switch stmt := stmt.Value.(type) {
+		case DeclareResource:
+			v.validateResource(parentPath, stmt)
+		case DeclareData:
+			v.validateData(parentPath, stmt)
+		case DeclareBuild:
+			outputs := v.validateBuild(parentPath, stmt)
+			for _, name := range instanceNames(Stmt{Value: stmt}) {
+				builds[name] = outputs
+			}
+		}
+	}
+
+	v.validateMoves(parentPath, moves, components)
+
+	for _, ref := range v.refs {
+		switch ref.kind {
+		case "resource":
+			if !resources[ref.name] {
+				v.addError(ref.path, "no resource %q in %s", ref.name, parentPath)
+			}
+			continue
+		case "data":
+			if !data[ref.name] {
+				v.addError(ref.path, "no data %q in %s", ref.name, parentPath)
+			}
+			continue
+		case "component":
+			if !components[ref.name] {
+				v.addError(ref.path, "no resource or build %q in %s", ref.name, parentPath)
+			}
+			continue
+		}
+
+		buildOutputs, ok := builds[ref.build]
+		if !ok {
+			v.addError(ref.path, "no build %q in %s", ref.build, parentPath)
+			continue
+		}
+
+		// The outputs of a build that could not be interpreted are unknown.
+		if buildOutputs != nil && !buildOutputs[ref.name] {
+			v.addError(ref.path, "build %q has no output %q", ref.build, ref.name)
+		}
+	}
+
+	return outputs
+}
+
+// instanceNames are the names that the instances of a statement can be referenced by.
+func instanceNames(stmt Stmt) []string {
+	stmts, err := Expand(stmt)
+	if err != nil {
+		// The problem is reported when the statement is validated.
+		return []string{stmtName(stmt)}
+	}
+
+	names := make([]string, len(stmts))
+	for i, s := range stmts {
+		names[i] = stmtName(s)
+	}
+
+	return names
+}
+
+func stmtName(stmt Stmt) string {
+	switch stmt := stmt.Value.(type) {
+	case DeclareResource:
+		return stmt.Name
+	case DeclareBuild:
+		return stmt.Name
+	case DeclareData:
+		return stmt.Name
+	default:
+		return ""
+	}
+}
+
+func (v *Validator) validateName(path, name string) {
+	if !namePattern.MatchString(name) {
+		v.addError(path, "invalid name %q: must start with a letter or underscore and contain only letters, digits, '-' and '_'", name)
+	}
+}
+
+func (v *Validator) validateBuild(parentPath string, build DeclareBuild) map[string]bool {
+	path := parentPath + "." + build.Name
+	v.validateName(path, build.Name)
+	v.validateRepetition(path, build.ForEach, build.Count)
+	defer func() { v.repeated = false }()
+
+	v.validateBool(path+".exists", build.Exists)
+	v.validateDependsOn(path+".depends_on", build.DependsOn)
+
+	if _, ok := build.Runtimeinput.Value.(MapCollection); ok {
+		v.validateValue(path+".runtime_input", build.Runtimeinput)
+	} else {
+		v.expected(path+".runtime_input", "map", build.Runtimeinput)
+	}
+
+	kinds := build.BlueprintSource.Kinds()
+	if len(kinds) == 0 {
+		v.addError(path+".source", "must provide a blueprint source")
+		return nil
+	}
+	if len(kinds) > 1 {
+		v.addError(path+".source", "must provide only one blueprint source, got %s", strings.Join(kinds, ", "))
+		return nil
+	}
+
+	blueprint, build, err := InterpretBuild(v.BlueprintInterpreter, build)
+	if err != nil {
+		v.addError(path+".source", "interpreting blueprint: %s", err)
+		return nil
+	}
+
+	v.validateInputs(path, build, blueprint)
+
+	return v.validateStmts(path, blueprint.Stmts)
+}
+
+// validateInputs checks the inputs a blueprint declares and what the build passes to them.
+func (v *Validator) validateInputs(path string, build DeclareBuild, blueprint Blueprint) {
+	seen := map[string]bool{}
+	for i, in := range blueprint.Inputs {
+		p := fmt.Sprintf("%s.inputs[%d]", path, i)
+		v.validateName(p, in.Name)
+		if seen[in.Name] {
+			v.addError(p, "duplicate input %q in blueprint %s", in.Name, build.BlueprintSource)
+		}
+		seen[in.Name] = true
+
+		if !slices.Contains(InputTypes, in.Type) {
+			v.addError(p+".type", "unsupported input type %q: must be one of %s", in.Type, strings.Join(InputTypes, ", "))
+			continue
+		}
+		if in.Default == nil {
+			continue
+		}
+		if in.Required {
+			v.addError(p+".default", "required input %q can't have a default", in.Name)
+		}
+		if kind := valueKind(in.Default); !inputAccepts(in.Type, kind) {
+			v.addError(p+".default", "default of input %q must be %s, got %s", in.Name, in.Type, kind)
+		}
+	}
+
+	v.errs = append(v.errs, CheckInputs(path, build, blueprint)...)
+}
+
+func (v *Validator) validateOutput(parentPath string, output DeclareOutput) {
+	path := parentPath + "." + output.Name
+	v.validateName(path, output.Name)
+	v.validateValue(path+".value", output.Value)
+}
+
+func (v *Validator) validateResource(parentPath string, resource DeclareResource) {
+	path := parentPath + "." + resource.Name
+	v.validateName(path, resource.Name)
+	v.validateRepetition(path, resource.ForEach, resource.Count)
+	defer func() { v.repeated = false }()
+
+	v.validateBool(path+".exists", resource.Exists)
+	v.validateString(path+".type", resource.Type)
+
+	v.validateProvider(path+".provider", resource.Provider)
+
+	v.validateValue(path+".identifier", resource.Identifier)
+	v.validateValue(path+".config", resource.Config)
+	v.validateLifecycle(path+".lifecycle", resource)
+	v.validateDependsOn(path+".depends_on", resource.DependsOn)
+}
+
+func (v *Validator) validateLifecycle(path string, resource DeclareResource) {
+	if exists, ok := resource.Exists.Value.(BoolLiteral); ok && !exists.Value && resource.Lifecycle.PreventDestroy {
+		v.addError(path+".prevent_destroy", "resource is protected from deletion but does not exist")
+	}
+
+	for i, p := range resource.Lifecycle.IgnoreChanges {
+		for _, segment := range strings.Split(p, ".") {
+			if segment == "" {
+				v.addError(fmt.Sprintf("%s.ignore_changes[%d]", path, i), "invalid config path %q", p)
+				break
+			}
+		}
+	}
+}
+
+func (v *Validator) validateData(parentPath string, data DeclareData) {
+	path := parentPath + "." + data.Name
+	v.validateName(path, data.Name)
+	v.validateString(path+".type", data.Type)
+	v.validateProvider(path+".provider", data.Provider)
+	v.validateValue(path+".identifier", data.Identifier)
+}
+
+// validateRepetition checks the for_each or count of a resource or build and allows each_key and each_value
+// in the rest of it if either is set.
+func (v *Validator) validateRepetition(path string, forEach, count Expr) {
+	if forEach.IsEmpty() && count.IsEmpty() {
+		return
+	}
+
+	if _, err := expandInstances(forEach, count); err != nil {
+		switch {
+		case forEach.IsEmpty():
+			path += ".count"
+		case count.IsEmpty():
+			path += ".for_each"
+		}
+		v.addError(path, "%s", err)
+	} else if _, ok := forEach.Value.(MapCollection); ok {
+		// The values of the map end up in the instances.
+		v.validateValue(path+".for_each", forEach)
+	}
+
+	v.repeated = true
+}
+
+// validateMoves checks that every move is from an address that is no longer declared. Where they move to is
+// checked like any other reference.
+func (v *Validator) validateMoves(parentPath string, moves []DeclareMoved, components map[string]bool) {
+	from := map[string]bool{}
+	to := map[string]bool{}
+	for i, move := range moves {
+		path := fmt.Sprintf("%s.moved[%d]", parentPath, i)
+		switch {
+		case move.From == "":
+			v.addError(path+".from", "missing address")
+		case move.From == move.To:
+			v.addError(path, "can't move %q to itself", move.From)
+		case from[move.From]:
+			v.addError(path+".from", "%q is moved more than once", move.From)
+		case components[move.From]:
+			v.addError(path+".from", "%q is still declared in %s", move.From, parentPath)
+		}
+		from[move.From] = true
+
+		if move.To != "" && move.From != move.To && to[move.To] {
+			v.addError(path+".to", "more than one address is moved to %q", move.To)
+		}
+		to[move.To] = true
+
+		v.validateAddress(path+".to", move.To)
+	}
+}
+
+func (v *Validator) validateDependsOn(path string, addresses []string) {
+	for i, address := range addresses {
+		v.validateAddress(fmt.Sprintf("%s[%d]", path, i), address)
+	}
+}
+
+// validateAddress checks an address of a resource or build, relative to the blueprint.
+func (v *Validator) validateAddress(path, address string) {
+	if address == "" {
+		v.addError(path, "missing address")
+		return
+	}
+
+	// Addresses into builds and of single instances are resolved once the blueprint is converted.
+	if !strings.ContainsAny(address, ".[") {
+		v.refs = append(v.refs, reference{path: path, kind: "component", name: address})
+	}
+}
+
+func (v *Validator) validateBool(path string, expr Expr) {
+	switch expr.Value.(type) {
+	case BoolLiteral:
+	case EachValue:
+		v.validateEach(path, expr)
+	default:
+		v.expected(path, "bool", expr)
+	}
+}
+
+func (v *Validator) validateEach(path string, expr Expr) {
+	if !v.repeated {
+		v.addError(path, "%s can only be used in a resource or build with for_each or count", describe(expr))
+	}
+}
+
+func (v *Validator) validateProvider(path string, expr Expr) {
+	if provider, ok := expr.Value.(Provider); ok {
+		v.validateString(path+".name", provider.Name)
+		v.validateString(path+".version", provider.Version)
+	} else {
+		v.expected(path, "provider", expr)
+	}
+}
+
+func (v *Validator) validateString(path string, expr Expr) {
+	switch expr.Value.(type) {
+	case StringLiteral:
+	case EachKey, EachValue:
+		v.validateEach(path, expr)
+	default:
+		v.expected(path, "string", expr)
+	}
+}
+
+// validateValue checks expressions that end up as resource or build values.
+func (v *Validator) validateValue(path string, expr Expr) {
+	switch value := expr.Value.(type) {
+	case StringLiteral, BoolLiteral, Null:
+	case EachKey, EachValue:
+		v.validateEach(path, expr)
+	case GetOutput:
+		v.refs = append(v.refs, reference{path: path, kind: "output", build: value.Build, name: value.Name})
+	case GetResource:
+		if !value.From.IsEmpty() {
+			v.addError(path+".from", "referencing resources in other builds is not supported, use an output instead")
+		}
+		v.refs = append(v.refs, reference{path: path, kind: "resource", name: value.Name})
+	case GetData:
+		v.refs = append(v.refs, reference{path: path, kind: "data", name: value.Name})
+	case Sensitive:
+		v.validateValue(path, value.Value)
+	case MapCollection:
+		keys := make([]string, 0, len(value.Value))
+		for k := range value.Value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			v.validateValue(path+"."+k, value.Value[k])
+		}
+	case nil:
+		v.addError(path, "missing required field")
+	default:
+		v.addError(path, "unsupported value expression %s", describe(expr))
+	}
+}
+
+func (v *Validator) expected(path, kind string, expr Expr) {
+	if expr.IsEmpty() {
+		v.addError(path, "missing required field")
+		return
+	}
+
+	v.addError(path, "expected %s expression, got %s", kind, describe(expr))
+}
+
+func describe(expr Expr) string {
+	if expr.Type != "" {
+		return fmt.Sprintf("%q", expr.Type)
+	}
+
+	return fmt.Sprintf("%T", expr.Value)
+}
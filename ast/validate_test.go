@@ -0,0 +1,325 @@
+package ast_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+type fakeInterpreter map[string]ast.Blueprint
+
+func (f fakeInterpreter) InterpretBlueprint(source ast.BlueprintSource, _ map[string]any) (ast.Blueprint, error) {
+	bp, ok := f[source.LocalFile.Path]
+	if !ok {
+		return ast.Blueprint{}, errors.New("not found")
+	}
+
+	return bp, nil
+}
+
+func str(s string) ast.Expr {
+	return ast.Expr{Type: "string", Value: ast.StringLiteral{Value: s}}
+}
+
+func boolean(b bool) ast.Expr {
+	return ast.Expr{Type: "bool", Value: ast.BoolLiteral{Value: b}}
+}
+
+func mapping(m map[string]ast.Expr) ast.Expr {
+	return ast.Expr{Type: "map", Value: ast.MapCollection{Value: m}}
+}
+
+func build(name, path string) ast.DeclareBuild {
+	return ast.DeclareBuild{
+		Name:            name,
+		Exists:          boolean(true),
+		Runtimeinput:    mapping(map[string]ast.Expr{}),
+		BlueprintSource: ast.BlueprintSource{LocalFile: ast.BlueprintSourceLocalFile{Path: path}},
+	}
+}
+
+func resource(name string) ast.DeclareResource {
+	return ast.DeclareResource{
+		Name:   name,
+		Exists: boolean(true),
+		Type:   str("bucket"),
+		Provider: ast.Expr{Type: "provider", Value: ast.Provider{
+			Name:    str("google-cloud"),
+			Version: str("v0.0.1"),
+		}},
+		Identifier: mapping(map[string]ast.Expr{"name": str(name)}),
+		Config:     mapping(map[string]ast.Expr{}),
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	missingExists := resource("no-exists")
+	missingExists.Exists = ast.Expr{}
+
+	wrongKind := resource("my-sub-resource")
+	wrongKind.Config = mapping(map[string]ast.Expr{
+		"thing": {Type: "integer", Value: ast.IntegerLiteral{Value: 1}},
+	})
+	wrongKind.Type = boolean(true)
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: resource("my-resource")},
+			{Type: "resource", Value: resource("my-resource")},
+			{Type: "resource", Value: resource("bad.name")},
+			{Type: "resource", Value: missingExists},
+			{Type: "build", Value: build("sub-build", "sub.wasm")},
+			{Type: "build", Value: build("missing-build", "missing.wasm")},
+		}},
+		"sub.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: wrongKind},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.my-resource", Message: `duplicate name "my-resource" in .Build`},
+		{Path: ".Build.bad.name", Message: `invalid name "bad.name": must start with a letter or underscore and contain only letters, digits, '-' and '_'`},
+		{Path: ".Build.no-exists.exists", Message: "missing required field"},
+		{Path: ".Build.sub-build.my-sub-resource.type", Message: `expected string expression, got "bool"`},
+		{Path: ".Build.sub-build.my-sub-resource.config.thing", Message: `unsupported value expression "integer"`},
+		{Path: ".Build.missing-build.source", Message: "interpreting blueprint: not found"},
+	}, errs)
+}
+
+func TestValidator_Valid(t *testing.T) {
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: resource("my-resource")},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	require.NoError(t, v.Validate("", build("Build", "root.wasm")))
+}
+
+func TestValidator_Outputs(t *testing.T) {
+	getOutput := func(b, name string) ast.Expr {
+		return ast.Expr{Type: "get_output", Value: ast.GetOutput{Build: b, Name: name}}
+	}
+
+	app := build("app", "app.wasm")
+	app.Runtimeinput = mapping(map[string]ast.Expr{"network": getOutput("network", "id")})
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "build", Value: build("network", "network.wasm")},
+			{Type: "build", Value: app},
+			{Type: "output", Value: ast.DeclareOutput{Name: "id", Value: getOutput("network", "id")}},
+			{Type: "output", Value: ast.DeclareOutput{Name: "missing", Value: getOutput("network", "missing")}},
+			{Type: "output", Value: ast.DeclareOutput{Name: "no-build", Value: getOutput("nope", "id")}},
+		}},
+		"network.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: resource("vpc")},
+			{Type: "output", Value: ast.DeclareOutput{
+				Name:  "id",
+				Value: ast.Expr{Type: "get_resource", Value: ast.GetResource{Name: "vpc"}},
+			}},
+			{Type: "output", Value: ast.DeclareOutput{
+				Name:  "id",
+				Value: ast.Expr{Type: "get_resource", Value: ast.GetResource{Name: "subnet"}},
+			}},
+		}},
+		"app.wasm": {},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.network.id", Message: `duplicate output "id" in .Build.network`},
+		{Path: ".Build.network.id.value", Message: `no resource "subnet" in .Build.network`},
+		{Path: ".Build.missing.value", Message: `build "network" has no output "missing"`},
+		{Path: ".Build.no-build.value", Message: `no build "nope" in .Build`},
+	}, errs)
+}
+
+func TestValidator_Data(t *testing.T) {
+	noIdentifier := ast.DeclareData{
+		Name: "no-identifier",
+		Type: str("network"),
+		Provider: ast.Expr{Type: "provider", Value: ast.Provider{
+			Name:    str("google-cloud"),
+			Version: str("v0.0.1"),
+		}},
+	}
+
+	shared := noIdentifier
+	shared.Name = "shared"
+	shared.Identifier = mapping(map[string]ast.Expr{"name": str("shared-vpc")})
+
+	res := resource("my-resource")
+	res.Config = mapping(map[string]ast.Expr{
+		"network": {Type: "get_data", Value: ast.GetData{Name: "shared"}},
+		"missing": {Type: "get_data", Value: ast.GetData{Name: "my-resource"}},
+	})
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "data", Value: shared},
+			{Type: "data", Value: noIdentifier},
+			{Type: "resource", Value: res},
+			{Type: "data", Value: shared},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.no-identifier.identifier", Message: "missing required field"},
+		{Path: ".Build.shared", Message: `duplicate name "shared" in .Build`},
+		{Path: ".Build.my-resource.config.missing", Message: `no data "my-resource" in .Build`},
+	}, errs)
+}
+
+func TestValidator_Repetition(t *testing.T) {
+	eachKey := ast.Expr{Type: "each_key", Value: ast.EachKey{}}
+
+	buckets := resource("buckets")
+	buckets.ForEach = list(str("us-east1"), str("eu-west1"))
+	buckets.Identifier = mapping(map[string]ast.Expr{"name": eachKey})
+
+	notRepeated := resource("not-repeated")
+	notRepeated.Identifier = mapping(map[string]ast.Expr{"name": eachKey})
+
+	badCount := resource("bad-count")
+	badCount.Count = str("2")
+
+	regions := build("regions", "region.wasm")
+	regions.Count = ast.Expr{Type: "integer", Value: ast.IntegerLiteral{Value: 2}}
+	regions.Runtimeinput = mapping(map[string]ast.Expr{"index": eachKey})
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: buckets},
+			{Type: "resource", Value: notRepeated},
+			{Type: "resource", Value: badCount},
+			{Type: "build", Value: regions},
+			{Type: "output", Value: ast.DeclareOutput{
+				Name:  "bucket",
+				Value: ast.Expr{Type: "get_resource", Value: ast.GetResource{Name: "buckets[us-east1]"}},
+			}},
+			{Type: "output", Value: ast.DeclareOutput{
+				Name:  "missing-key",
+				Value: ast.Expr{Type: "get_resource", Value: ast.GetResource{Name: "buckets[asia1]"}},
+			}},
+			{Type: "output", Value: ast.DeclareOutput{
+				Name:  "region",
+				Value: ast.Expr{Type: "get_output", Value: ast.GetOutput{Build: "regions[1]", Name: "id"}},
+			}},
+		}},
+		"region.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: notRepeated},
+			{Type: "output", Value: ast.DeclareOutput{Name: "id", Value: str("id")}},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.not-repeated.identifier.name", Message: `"each_key" can only be used in a resource or build with for_each or count`},
+		{Path: ".Build.bad-count.count", Message: `count must be an integer, got "string"`},
+		// Statements inside a repeated build can't use its key.
+		{Path: ".Build.regions.not-repeated.identifier.name", Message: `"each_key" can only be used in a resource or build with for_each or count`},
+		{Path: ".Build.missing-key.value", Message: `no resource "buckets[asia1]" in .Build`},
+	}, errs)
+}
+
+func TestValidator_Lifecycle(t *testing.T) {
+	protected := resource("protected")
+	protected.Exists = boolean(false)
+	protected.Lifecycle.PreventDestroy = true
+
+	ignored := resource("ignored")
+	ignored.Lifecycle.IgnoreChanges = []string{"labels.env", "labels..env"}
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: protected},
+			{Type: "resource", Value: ignored},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.protected.lifecycle.prevent_destroy", Message: "resource is protected from deletion but does not exist"},
+		{Path: ".Build.ignored.lifecycle.ignore_changes[1]", Message: `invalid config path "labels..env"`},
+	}, errs)
+}
+
+func TestValidator_DependsOn(t *testing.T) {
+	app := resource("app")
+	app.DependsOn = []string{"db", "network.vpc", "buckets[a]", "missing", ""}
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: app},
+			{Type: "resource", Value: resource("db")},
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.app.depends_on[4]", Message: "missing address"},
+		{Path: ".Build.app.depends_on[3]", Message: `no resource or build "missing" in .Build`},
+	}, errs)
+}
+
+func TestValidator_Moved(t *testing.T) {
+	moved := func(from, to string) ast.Stmt {
+		return ast.Stmt{Type: "moved", Value: ast.DeclareMoved{From: from, To: to}}
+	}
+
+	in := fakeInterpreter{
+		"root.wasm": {Stmts: []ast.Stmt{
+			{Type: "resource", Value: resource("bucket")},
+			{Type: "resource", Value: resource("old-db")},
+			moved("old-bucket", "bucket"),
+			moved("old-bucket", "network.vpc"),
+			moved("old-db", "db"),
+			moved("bucket", "bucket"),
+			moved("older-bucket", "bucket"),
+		}},
+	}
+
+	v := ast.Validator{BlueprintInterpreter: in}
+	err := v.Validate("", build("Build", "root.wasm"))
+
+	var errs ast.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Equal(t, ast.ValidationErrors{
+		{Path: ".Build.moved[1].from", Message: `"old-bucket" is moved more than once`},
+		{Path: ".Build.moved[2].from", Message: `"old-db" is still declared in .Build`},
+		{Path: ".Build.moved[3]", Message: `can't move "bucket" to itself`},
+		{Path: ".Build.moved[4].to", Message: `more than one address is moved to "bucket"`},
+		{Path: ".Build.moved[2].to", Message: `no resource or build "db" in .Build`},
+	}, errs)
+}
@@ -0,0 +1,166 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// FormatVersion is the version of the blueprint format that this package reads into memory and writes.
+	FormatVersion = 3
+
+	// MinFormatVersion is the oldest version that can still be migrated to FormatVersion.
+	MinFormatVersion = 1
+)
+
+// migrations upgrade a decoded blueprint document from the version they are keyed by to the next one.
+var migrations = map[int]func(doc map[string]any) map[string]any{
+	1: migrateV1,
+	2: migrateV2,
+}
+
+// MigrateBlueprint upgrades an encoded blueprint document to FormatVersion. Documents without a version are
+// version 1, the format used before versions were introduced.
+func MigrateBlueprint(data []byte) ([]byte, error) {
+	migrated, _, err := migrateBlueprint(data)
+	return migrated, err
+}
+
+// migrateBlueprint is MigrateBlueprint, but also returns the version the document was written in.
+func migrateBlueprint(data []byte) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+	if doc == nil {
+		return nil, 0, errors.New("blueprint must be a JSON object")
+	}
+
+	version := 1
+	if v, ok := doc["version"]; ok {
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, 0, fmt.Errorf("blueprint version must be an integer, got %v", v)
+		}
+
+		i, err := n.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("blueprint version must be an integer, got %v", v)
+		}
+
+		version = int(i)
+	}
+
+	if version < MinFormatVersion {
+		return nil, 0, fmt.Errorf("blueprint format version %d is too old: the oldest supported version is %d", version, MinFormatVersion)
+	}
+	if version > FormatVersion {
+		return nil, 0, fmt.Errorf("blueprint format version %d is too new: the newest supported version is %d, upgrade athanor to use this blueprint", version, FormatVersion)
+	}
+
+	// Nested blueprints are migrated when they are decoded. Stamp them with the version of the document they
+	// were written in.
+	stampInlineVersions(doc, version)
+
+	for v := version; v < FormatVersion; v++ {
+		doc = migrations[v](doc)
+	}
+	doc["version"] = FormatVersion
+
+	migrated, err := json.Marshal(doc)
+	return migrated, version, err
+}
+
+func (b *Blueprint) UnmarshalJSON(data []byte) error {
+	migrated, version, err := migrateBlueprint(data)
+	if err != nil {
+		return err
+	}
+
+	// Decode into a type without this method to avoid recursing.
+	type blueprint Blueprint
+	var out blueprint
+	if err := json.Unmarshal(migrated, &out); err != nil {
+		return err
+	}
+
+	*b = Blueprint(out)
+	b.Version = version
+	return nil
+}
+
+// stampInlineVersions stamps the inline blueprints of the build statements in doc that don't have a version.
+// Only build sources are looked at, since user data such as build input can have keys named "inline" too. Inline
+// blueprints nested deeper are stamped when their parent is decoded.
+func stampInlineVersions(doc map[string]any, version int) {
+	stmts, _ := doc["stmts"].([]any)
+	for _, stmt := range stmts {
+		stmt, _ := stmt.(map[string]any)
+		value, _ := stmt["value"].(map[string]any)
+		source, _ := value["source"].(map[string]any)
+		inline, ok := source["inline"].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if _, ok := inline["version"]; !ok {
+			inline["version"] = version
+		}
+	}
+}
+
+// migrateV1 upgrades documents written before Expr had JSON tags. Expressions were encoded with "Type" and
+// "Value" keys, and expressions that were never set as {"Type": "", "Value": null}.
+func migrateV1(doc map[string]any) map[string]any {
+	return migrateV1Value(doc).(map[string]any)
+}
+
+// migrateV2 upgrades version 2 documents. Version 3 doesn't change the shape of a document, only how the sources
+// of its builds are resolved: relative to the blueprint that declares them rather than to the working directory
+// (see RelativeSourcesVersion). The document is left as it is, and the version it was written in is kept on the
+// blueprint so that its sources are still resolved the old way.
+func migrateV2(doc map[string]any) map[string]any {
+	return doc
+}
+
+func migrateV1Value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if t, ok := v["Type"]; ok && len(v) == 2 {
+			if value, ok := v["Value"]; ok {
+				if t == "" && value == nil {
+					return nil
+				}
+
+				return map[string]any{"type": t, "value": migrateV1Value(value)}
+			}
+		}
+
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			switch k {
+			case "input", "inline":
+				// Build input is user data, and nested blueprints are migrated on their own.
+				out[k] = val
+			default:
+				out[k] = migrateV1Value(val)
+			}
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = migrateV1Value(val)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+func TestBlueprint_MigrateV1(t *testing.T) {
+	// Written by a blueprint compiled before the format was versioned.
+	v1 := `{
+	  "stmts": [
+	    {
+	      "type": "resource",
+	      "value": {
+	        "name": "my-resource",
+	        "exists": {"Type": "bool", "Value": {"bool_literal": true}},
+	        "type": {"Type": "string", "Value": {"string_literal": "bucket"}},
+	        "provider": {"Type": "provider", "Value": {
+	          "name": {"Type": "string", "Value": {"string_literal": "google-cloud"}},
+	          "version": {"Type": "string", "Value": {"string_literal": "v0.0.1"}}
+	        }},
+	        "identifier": {"Type": "map", "Value": {"map_collection": {
+	          "name": {"Type": "string", "Value": {"string_literal": "my-resource"}}
+	        }}},
+	        "config": {"Type": "", "Value": null}
+	      }
+	    },
+	    {
+	      "type": "build",
+	      "value": {
+	        "name": "sub-build",
+	        "exists": {"Type": "bool", "Value": {"bool_literal": true}},
+	        "input": {"Type": "kept", "Value": null},
+	        "runtime_input": {"Type": "map", "Value": {"map_collection": {}}},
+	        "source": {"local_file": {"path": "./sub/main.wasm"}}
+	      }
+	    }
+	  ]
+	}`
+
+	res := resource("my-resource")
+	res.Config = ast.Expr{}
+
+	b := build("sub-build", "./sub/main.wasm")
+	b.Input = map[string]any{"Type": "kept", "Value": nil}
+
+	var bp ast.Blueprint
+	require.NoError(t, json.Unmarshal([]byte(v1), &bp))
+	require.Equal(t, ast.Blueprint{
+		Version: 1,
+		Stmts: []ast.Stmt{
+			{Type: "resource", Value: res},
+			{Type: "build", Value: b},
+		},
+	}, bp)
+}
+
+func TestBlueprint_MigrateInline(t *testing.T) {
+	v1 := `{
+	  "stmts": [
+	    {
+	      "type": "build",
+	      "value": {
+	        "name": "inline-build",
+	        "exists": {"Type": "bool", "Value": {"bool_literal": true}},
+	        "input": null,
+	        "runtime_input": {"Type": "map", "Value": {"map_collection": {}}},
+	        "source": {"inline": {"stmts": []}}
+	      }
+	    }
+	  ]
+	}`
+
+	var bp ast.Blueprint
+	require.NoError(t, json.Unmarshal([]byte(v1), &bp))
+
+	b, ok := bp.Stmts[0].Value.(ast.DeclareBuild)
+	require.True(t, ok)
+	require.Equal(t, boolean(true), b.Exists)
+	require.NotNil(t, b.BlueprintSource.Inline)
+	require.Equal(t, 1, b.BlueprintSource.Inline.Version)
+}
+
+func TestBlueprint_MigrateInlineSkipsUserData(t *testing.T) {
+	v2 := `{
+	  "version": 2,
+	  "stmts": [
+	    {
+	      "type": "build",
+	      "value": {
+	        "name": "sub-build",
+	        "exists": {"type": "bool", "value": {"bool_literal": true}},
+	        "input": {"inline": {"name": "kept"}},
+	        "runtime_input": {"type": "map", "value": {"map_collection": {
+	          "inline": {"type": "map", "value": {"map_collection": {}}}
+	        }}},
+	        "source": {"local_file": {"path": "./sub/main.wasm"}}
+	      }
+	    }
+	  ]
+	}`
+
+	var bp ast.Blueprint
+	require.NoError(t, json.Unmarshal([]byte(v2), &bp))
+
+	b, ok := bp.Stmts[0].Value.(ast.DeclareBuild)
+	require.True(t, ok)
+	require.Equal(t, map[string]any{"inline": map[string]any{"name": "kept"}}, b.Input)
+	require.Equal(t, ast.Expr{Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{
+		"inline": {Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{}}},
+	}}}, b.Runtimeinput)
+}
+
+func TestBlueprint_UnsupportedVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{
+			name: "too old",
+			doc:  `{"version": 0, "stmts": []}`,
+			err:  "blueprint format version 0 is too old: the oldest supported version is 1",
+		},
+		{
+			name: "too new",
+			doc:  `{"version": 99, "stmts": []}`,
+			err:  "blueprint format version 99 is too new: the newest supported version is 3, upgrade athanor to use this blueprint",
+		},
+		{
+			name: "not an integer",
+			doc:  `{"version": "2", "stmts": []}`,
+			err:  "blueprint version must be an integer, got 2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var bp ast.Blueprint
+			require.EqualError(t, json.Unmarshal([]byte(test.doc), &bp), test.err)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Package blueprint is a small DSL for writing blueprints in Go. It works in programs compiled with
+// GOOS=wasip1, which is how blueprints are usually run:
+//
+//	func main() {
+//		gcp := blueprint.Provider("google-cloud", "v0.0.1")
+//		bp := blueprint.Build(
+//			blueprint.Resource("my-bucket", "bucket", gcp).
+//				Identifier(blueprint.Map{"name": blueprint.String("my-bucket")}).
+//				Config(blueprint.Map{"location": blueprint.String("us-east1")}),
+//		)
+//		if err := bp.Write(); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package blueprint
+
+import (
+	"os"
+
+	"github.com/alchematik/athanor/ast"
+)
+
+// OutputFile is where Write puts the blueprint. Athanor reads it once the program exits.
+const OutputFile = "blueprint.json"
+
+// Stmt is a statement in a blueprint.
+type Stmt interface {
+	Stmt() ast.Stmt
+}
+
+type Blueprint struct {
+	stmts  []Stmt
+	inputs []ast.Input
+}
+
+func Build(stmts ...Stmt) *Blueprint {
+	return &Blueprint{stmts: stmts}
+}
+
+// Add appends statements to the blueprint.
+func (b *Blueprint) Add(stmts ...Stmt) *Blueprint {
+	b.stmts = append(b.stmts, stmts...)
+	return b
+}
+
+// Accepts declares the inputs of the blueprint. Builds of it can only pass these.
+func (b *Blueprint) Accepts(inputs ...*InputDecl) *Blueprint {
+	if b.inputs == nil {
+		b.inputs = []ast.Input{}
+	}
+	for _, in := range inputs {
+		b.inputs = append(b.inputs, in.input)
+	}
+
+	return b
+}
+
+func (b *Blueprint) AST() ast.Blueprint {
+	out := ast.Blueprint{Version: ast.FormatVersion, Stmts: make([]ast.Stmt, len(b.stmts)), Inputs: b.inputs}
+	for i, s := range b.stmts {
+		out.Stmts[i] = s.Stmt()
+	}
+
+	return out
+}
+
+// Write encodes the blueprint to OutputFile.
+func (b *Blueprint) Write() error {
+	data, err := ast.MarshalBlueprint(b.AST())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(OutputFile, data, 0o644)
+}
+
+// InputDecl is an input that a blueprint accepts.
+type InputDecl struct {
+	input ast.Input
+}
+
+// Input declares an optional input of type t, which is one of ast.InputTypes.
+func Input(name, t string) *InputDecl {
+	return &InputDecl{input: ast.Input{Name: name, Type: t}}
+}
+
+func (i *InputDecl) Default(v any) *InputDecl {
+	i.input.Default = v
+	return i
+}
+
+func (i *InputDecl) Required() *InputDecl {
+	i.input.Required = true
+	return i
+}
+
+func (i *InputDecl) Description(description string) *InputDecl {
+	i.input.Description = description
+	return i
+}
+
+type ResourceStmt struct {
+	resource ast.DeclareResource
+}
+
+// Resource declares a resource that exists, with an empty identifier and config.
+func Resource(name, resourceType string, provider Value) *ResourceStmt {
+	return &ResourceStmt{
+		resource: ast.DeclareResource{
+			Name:       name,
+			Exists:     Bool(true).Expr(),
+			Type:       String(resourceType).Expr(),
+			Provider:   provider.Expr(),
+			Identifier: Map{}.Expr(),
+			Config:     Map{}.Expr(),
+		},
+	}
+}
+
+func (r *ResourceStmt) Exists(exists bool) *ResourceStmt {
+	r.resource.Exists = Bool(exists).Expr()
+	return r
+}
+
+func (r *ResourceStmt) Identifier(v Value) *ResourceStmt {
+	r.resource.Identifier = v.Expr()
+	return r
+}
+
+func (r *ResourceStmt) Config(v Value) *ResourceStmt {
+	r.resource.Config = v.Expr()
+	return r
+}
+
+// PreventDestroy fails any diff that would delete or replace the resource.
+func (r *ResourceStmt) PreventDestroy() *ResourceStmt {
+	r.resource.Lifecycle.PreventDestroy = true
+	return r
+}
+
+// IgnoreChanges never applies changes to the config at the given dot separated paths, such as `labels.env`.
+func (r *ResourceStmt) IgnoreChanges(paths ...string) *ResourceStmt {
+	r.resource.Lifecycle.IgnoreChanges = append(r.resource.Lifecycle.IgnoreChanges, paths...)
+	return r
+}
+
+// CreateBeforeDestroy creates the replacement of the resource before deleting it.
+func (r *ResourceStmt) CreateBeforeDestroy() *ResourceStmt {
+	r.resource.Lifecycle.CreateBeforeDestroy = true
+	return r
+}
+
+// ForEach declares one instance of the resource per key of a Map or per string of a List. Use EachKey and
+// EachValue to refer to the instance.
+func (r *ResourceStmt) ForEach(v Value) *ResourceStmt {
+	r.resource.ForEach = v.Expr()
+	return r
+}
+
+// Count declares n instances of the resource, keyed by their index.
+func (r *ResourceStmt) Count(n int) *ResourceStmt {
+	r.resource.Count = Int(n).Expr()
+	return r
+}
+
+// DependsOn makes the resource wait for the resources and builds at addresses, such as `vpc` or `network.vpc`.
+func (r *ResourceStmt) DependsOn(addresses ...string) *ResourceStmt {
+	r.resource.DependsOn = append(r.resource.DependsOn, addresses...)
+	return r
+}
+
+func (r *ResourceStmt) Stmt() ast.Stmt {
+	return ast.Stmt{Type: "resource", Value: r.resource}
+}
+
+type DataStmt struct {
+	data ast.DeclareData
+}
+
+// Data declares a read-only lookup of an existing object, with an empty identifier.
+func Data(name, resourceType string, provider Value) *DataStmt {
+	return &DataStmt{
+		data: ast.DeclareData{
+			Name:       name,
+			Type:       String(resourceType).Expr(),
+			Provider:   provider.Expr(),
+			Identifier: Map{}.Expr(),
+		},
+	}
+}
+
+func (d *DataStmt) Identifier(v Value) *DataStmt {
+	d.data.Identifier = v.Expr()
+	return d
+}
+
+func (d *DataStmt) Stmt() ast.Stmt {
+	return ast.Stmt{Type: "data", Value: d.data}
+}
+
+type BuildStmt struct {
+	build ast.DeclareBuild
+}
+
+// SubBuild declares a build of the blueprint at path, which is either a WebAssembly program or a JSON or YAML
+// document. Relative paths are relative to the directory of this blueprint.
+func SubBuild(name, path string) *BuildStmt {
+	return &BuildStmt{
+		build: ast.DeclareBuild{
+			Name:            name,
+			Exists:          Bool(true).Expr(),
+			Runtimeinput:    Map{}.Expr(),
+			BlueprintSource: ast.SourceFromPath(path),
+		},
+	}
+}
+
+// InlineBuild declares a build of a blueprint that is embedded in this one.
+func InlineBuild(name string, blueprint *Blueprint) *BuildStmt {
+	inline := blueprint.AST()
+	b := SubBuild(name, "")
+	b.build.BlueprintSource = ast.BlueprintSource{Inline: &inline}
+	return b
+}
+
+// ExecBuild declares a build of the blueprint that command, run with args on the host, writes to stdout.
+func ExecBuild(name, command string, args ...string) *BuildStmt {
+	b := SubBuild(name, "")
+	b.build.BlueprintSource = ast.BlueprintSource{Exec: ast.BlueprintSourceExec{Command: command, Args: args}}
+	return b
+}
+
+// PackageBuild declares a build of the blueprint in version of the package name. An empty version uses the
+// version in the lock file, or else the newest one.
+func PackageBuild(name, pkg, version string) *BuildStmt {
+	b := SubBuild(name, "")
+	b.build.BlueprintSource = ast.BlueprintSource{Package: ast.BlueprintSourcePackage{Name: pkg, Version: version}}
+	return b
+}
+
+// Checksum pins the archive of the build's package to the "sha256:<hex>" digest.
+func (b *BuildStmt) Checksum(checksum string) *BuildStmt {
+	b.build.BlueprintSource.Package.Checksum = checksum
+	return b
+}
+
+// Bundle gives the WebAssembly program of the build read-only access to the files in dir at /bundle. dir is
+// relative to the directory of this blueprint.
+func (b *BuildStmt) Bundle(dir string) *BuildStmt {
+	b.build.BlueprintSource.LocalFile.Bundle = dir
+	return b
+}
+
+func (b *BuildStmt) Exists(exists bool) *BuildStmt {
+	b.build.Exists = Bool(exists).Expr()
+	return b
+}
+
+func (b *BuildStmt) Input(input map[string]any) *BuildStmt {
+	b.build.Input = input
+	return b
+}
+
+func (b *BuildStmt) RuntimeInput(input Map) *BuildStmt {
+	b.build.Runtimeinput = input.Expr()
+	return b
+}
+
+// ForEach declares one instance of the build per key of a Map or per string of a List. Use EachKey and
+// EachValue to refer to the instance in its runtime input.
+func (b *BuildStmt) ForEach(v Value) *BuildStmt {
+	b.build.ForEach = v.Expr()
+	return b
+}
+
+// Count declares n instances of the build, keyed by their index.
+func (b *BuildStmt) Count(n int) *BuildStmt {
+	b.build.Count = Int(n).Expr()
+	return b
+}
+
+// DependsOn makes the build wait for the resources and builds at addresses, such as `vpc` or `network.vpc`.
+func (b *BuildStmt) DependsOn(addresses ...string) *BuildStmt {
+	b.build.DependsOn = append(b.build.DependsOn, addresses...)
+	return b
+}
+
+func (b *BuildStmt) Stmt() ast.Stmt {
+	return ast.Stmt{Type: "build", Value: b.build}
+}
+
+type OutputStmt struct {
+	output ast.DeclareOutput
+}
+
+// Output exposes v to the blueprint that declares this build.
+func Output(name string, v Value) *OutputStmt {
+	return &OutputStmt{output: ast.DeclareOutput{Name: name, Value: v.Expr()}}
+}
+
+func (o *OutputStmt) Stmt() ast.Stmt {
+	return ast.Stmt{Type: "output", Value: o.output}
+}
+
+type MovedStmt struct {
+	moved ast.DeclareMoved
+}
+
+// Moved records that the resource or build at the address from is now at to, such as after renaming it or
+// moving it into a build, so that it isn't deleted and created again.
+func Moved(from, to string) *MovedStmt {
+	return &MovedStmt{moved: ast.DeclareMoved{From: from, To: to}}
+}
+
+func (m *MovedStmt) Stmt() ast.Stmt {
+	return ast.Stmt{Type: "moved", Value: m.moved}
+}
@@ -0,0 +1,84 @@
+package blueprint_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+	bp "github.com/alchematik/athanor/blueprint"
+)
+
+func TestBuild(t *testing.T) {
+	gcp := bp.Provider("google-cloud", "v0.0.1")
+	blueprint := bp.Build(
+		bp.Resource("my-bucket", "bucket", gcp).
+			Identifier(bp.Map{"name": bp.String("my-bucket")}).
+			Config(bp.Map{
+				"versioning": bp.Bool(true),
+				"password":   bp.Sensitive(bp.String("hunter2")),
+				"labels":     bp.Null(),
+			}),
+		bp.SubBuild("sub", "./sub/main.wasm").
+			Input(map[string]any{"region": "us-east1"}).
+			Exists(false),
+	)
+
+	str := func(s string) ast.Expr { return ast.Expr{Type: "string", Value: ast.StringLiteral{Value: s}} }
+	require.Equal(t, ast.Blueprint{
+		Version: ast.FormatVersion,
+		Stmts: []ast.Stmt{
+			{
+				Type: "resource",
+				Value: ast.DeclareResource{
+					Name:   "my-bucket",
+					Exists: ast.Expr{Type: "bool", Value: ast.BoolLiteral{Value: true}},
+					Type:   str("bucket"),
+					Provider: ast.Expr{Type: "provider", Value: ast.Provider{
+						Name:    str("google-cloud"),
+						Version: str("v0.0.1"),
+					}},
+					Identifier: ast.Expr{Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{
+						"name": str("my-bucket"),
+					}}},
+					Config: ast.Expr{Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{
+						"versioning": {Type: "bool", Value: ast.BoolLiteral{Value: true}},
+						"password":   {Type: "sensitive", Value: ast.Sensitive{Value: str("hunter2")}},
+						"labels":     {Type: "null", Value: ast.Null{}},
+					}}},
+				},
+			},
+			{
+				Type: "build",
+				Value: ast.DeclareBuild{
+					Name:         "sub",
+					Exists:       ast.Expr{Type: "bool", Value: ast.BoolLiteral{Value: false}},
+					Input:        map[string]any{"region": "us-east1"},
+					Runtimeinput: ast.Expr{Type: "map", Value: ast.MapCollection{Value: map[string]ast.Expr{}}},
+					BlueprintSource: ast.BlueprintSource{
+						LocalFile: ast.BlueprintSourceLocalFile{Path: "./sub/main.wasm"},
+					},
+				},
+			},
+		},
+	}, blueprint.AST())
+}
+
+func TestBlueprint_Write(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	blueprint := bp.Build(bp.Resource("my-bucket", "bucket", bp.Provider("google-cloud", "v0.0.1")))
+	require.NoError(t, blueprint.Write())
+
+	data, err := os.ReadFile(bp.OutputFile)
+	require.NoError(t, err)
+
+	var decoded ast.Blueprint
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, blueprint.AST(), decoded)
+}
@@ -0,0 +1,117 @@
+// Package host gives blueprint programs access to the host functions that Athanor provides to them. The
+// functions are only available to programs compiled with GOOS=wasip1 and run by Athanor. Elsewhere, they return
+// ErrUnavailable.
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+)
+
+// These match the codes that the host functions return.
+const (
+	notFound   int32 = -1
+	notAllowed int32 = -2
+	// unavailable is only returned when not running under Athanor.
+	unavailable int32 = -3
+)
+
+var (
+	// ErrNotAllowed is returned when a blueprint program asks for an environment variable it isn't allowed to
+	// read, or for a file that is outside of its directory or isn't a regular file.
+	ErrNotAllowed = errors.New("not allowed")
+	// ErrUnavailable is returned when the host functions aren't available.
+	ErrUnavailable = errors.New("host functions are only available to wasip1 blueprint programs run by athanor")
+)
+
+// call calls a host function that returns data, growing the buffer until the data fits.
+func call(f func(buf []byte) int32) ([]byte, int32) {
+	buf := make([]byte, 256)
+	for {
+		n := f(buf)
+		if n < 0 {
+			return nil, n
+		}
+		if int(n) <= len(buf) {
+			return buf[:n], n
+		}
+
+		buf = make([]byte, n)
+	}
+}
+
+func codeError(code int32, notFoundErr error) error {
+	switch code {
+	case notFound:
+		return notFoundErr
+	case notAllowed:
+		return ErrNotAllowed
+	case unavailable:
+		return ErrUnavailable
+	default:
+		return fmt.Errorf("unexpected host function result: %d", code)
+	}
+}
+
+// Input returns the input that the build passes to the blueprint.
+func Input() (map[string]any, error) {
+	data, code := call(input)
+	if code < 0 {
+		return nil, codeError(code, errors.New("no input"))
+	}
+
+	var in map[string]any
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("decoding input: %w", err)
+	}
+
+	return in, nil
+}
+
+// Env returns the value of the environment variable name. Blueprint programs can only read the variables that
+// Athanor is told to allow, and get ErrNotAllowed for the others.
+func Env(name string) (string, bool, error) {
+	data, code := call(func(buf []byte) int32 { return env(name, buf) })
+	if code == notFound {
+		return "", false, nil
+	}
+	if code < 0 {
+		return "", false, codeError(code, nil)
+	}
+
+	return string(data), true, nil
+}
+
+// ReadFile returns the contents of the regular file at path, relative to the directory of the blueprint program.
+// Symlinks may only lead to files in that directory.
+func ReadFile(path string) ([]byte, error) {
+	data, code := call(func(buf []byte) int32 { return readFile(path, buf) })
+	if code < 0 {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: codeError(code, fs.ErrNotExist)}
+	}
+
+	return data, nil
+}
+
+// Log logs msg with Athanor's logger. args are key-value pairs or slog.Attrs, like those of slog.Log.
+func Log(level slog.Level, msg string, args ...any) {
+	var attrs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&attrs, &slog.HandlerOptions{
+		Level: slog.LevelDebug - 100,
+		// Only the attributes are passed on, since the host has its own time, level and message.
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+	logger.Info("", args...)
+
+	log(int32(level), msg, bytes.TrimSpace(attrs.Bytes()))
+}
@@ -0,0 +1,26 @@
+//go:build !wasip1
+
+package host
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+func input(buf []byte) int32 {
+	return unavailable
+}
+
+func env(name string, buf []byte) int32 {
+	return unavailable
+}
+
+func readFile(path string, buf []byte) int32 {
+	return unavailable
+}
+
+// log writes to stderr, so that programs still log when run on their own.
+func log(level int32, msg string, attrs []byte) {
+	fmt.Fprintf(os.Stderr, "%s %s %s\n", slog.Level(level), msg, attrs)
+}
@@ -0,0 +1,43 @@
+//go:build wasip1
+
+package host
+
+import "unsafe"
+
+//go:wasmimport athanor input
+func hostInput(buf unsafe.Pointer, bufLen int32) int32
+
+//go:wasmimport athanor env
+func hostEnv(name unsafe.Pointer, nameLen int32, buf unsafe.Pointer, bufLen int32) int32
+
+//go:wasmimport athanor read_file
+func hostReadFile(path unsafe.Pointer, pathLen int32, buf unsafe.Pointer, bufLen int32) int32
+
+//go:wasmimport athanor log
+func hostLog(level int32, msg unsafe.Pointer, msgLen int32, attrs unsafe.Pointer, attrsLen int32)
+
+func input(buf []byte) int32 {
+	return hostInput(unsafe.Pointer(unsafe.SliceData(buf)), int32(len(buf)))
+}
+
+func env(name string, buf []byte) int32 {
+	return hostEnv(
+		unsafe.Pointer(unsafe.StringData(name)), int32(len(name)),
+		unsafe.Pointer(unsafe.SliceData(buf)), int32(len(buf)),
+	)
+}
+
+func readFile(path string, buf []byte) int32 {
+	return hostReadFile(
+		unsafe.Pointer(unsafe.StringData(path)), int32(len(path)),
+		unsafe.Pointer(unsafe.SliceData(buf)), int32(len(buf)),
+	)
+}
+
+func log(level int32, msg string, attrs []byte) {
+	hostLog(
+		level,
+		unsafe.Pointer(unsafe.StringData(msg)), int32(len(msg)),
+		unsafe.Pointer(unsafe.SliceData(attrs)), int32(len(attrs)),
+	)
+}
@@ -0,0 +1,99 @@
+package blueprint
+
+import (
+	"github.com/alchematik/athanor/ast"
+)
+
+// Value is anything that can be used as the value of an expression in a blueprint.
+type Value interface {
+	Expr() ast.Expr
+}
+
+type String string
+
+func (s String) Expr() ast.Expr {
+	return ast.Expr{Type: "string", Value: ast.StringLiteral{Value: string(s)}}
+}
+
+type Bool bool
+
+func (b Bool) Expr() ast.Expr {
+	return ast.Expr{Type: "bool", Value: ast.BoolLiteral{Value: bool(b)}}
+}
+
+type Int int
+
+func (i Int) Expr() ast.Expr {
+	return ast.Expr{Type: "integer", Value: ast.IntegerLiteral{Value: int(i)}}
+}
+
+type Map map[string]Value
+
+func (m Map) Expr() ast.Expr {
+	values := make(map[string]ast.Expr, len(m))
+	for k, v := range m {
+		values[k] = v.Expr()
+	}
+
+	return ast.Expr{Type: "map", Value: ast.MapCollection{Value: values}}
+}
+
+// List can only be used as the ForEach of a resource or build.
+type List []Value
+
+func (l List) Expr() ast.Expr {
+	values := make([]ast.Expr, len(l))
+	for i, v := range l {
+		values[i] = v.Expr()
+	}
+
+	return ast.Expr{Type: "list", Value: ast.ListCollection{Value: values}}
+}
+
+type expr ast.Expr
+
+func (e expr) Expr() ast.Expr {
+	return ast.Expr(e)
+}
+
+// Null explicitly unsets a value.
+func Null() Value {
+	return expr{Type: "null", Value: ast.Null{}}
+}
+
+// Sensitive marks v as secret so that it is never displayed.
+func Sensitive(v Value) Value {
+	return expr{Type: "sensitive", Value: ast.Sensitive{Value: v.Expr()}}
+}
+
+func Provider(name, version string) Value {
+	return expr{Type: "provider", Value: ast.Provider{
+		Name:    String(name).Expr(),
+		Version: String(version).Expr(),
+	}}
+}
+
+// GetOutput is the value of the output name declared by build, a build in the same blueprint.
+func GetOutput(build, name string) Value {
+	return expr{Type: "get_output", Value: ast.GetOutput{Build: build, Name: name}}
+}
+
+// GetResource is the identifier, config and attributes of the resource name in the same blueprint.
+func GetResource(name string) Value {
+	return expr{Type: "get_resource", Value: ast.GetResource{Name: name}}
+}
+
+// GetData is the identifier, config and attributes of the data lookup name in the same blueprint.
+func GetData(name string) Value {
+	return expr{Type: "get_data", Value: ast.GetData{Name: name}}
+}
+
+// EachKey is the key of the instance being declared by a resource or build with ForEach or Count.
+func EachKey() Value {
+	return expr{Type: "each_key", Value: ast.EachKey{}}
+}
+
+// EachValue is the value of the instance being declared by a resource or build with ForEach or Count.
+func EachValue() Value {
+	return expr{Type: "each_value", Value: ast.EachValue{}}
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/urfave/cli/v3"
 
+	"github.com/alchematik/athanor/internal/cli/format"
+	"github.com/alchematik/athanor/internal/cli/schema"
 	"github.com/alchematik/athanor/internal/cli/show"
+	"github.com/alchematik/athanor/internal/cli/validate"
 )
 
 func main() {
@@ -22,6 +25,9 @@ func main() {
 					show.NewDiffCommand(),
 				},
 			},
+			validate.NewValidateCommand(),
+			schema.NewSchemaCommand(),
+			format.NewFormatCommand(),
 		},
 	}
 
@@ -0,0 +1,75 @@
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	external_ast "github.com/alchematik/athanor/ast"
+
+	"github.com/urfave/cli/v3"
+)
+
+func NewFormatCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "fmt",
+		Usage:     "rewrite blueprint JSON files in canonical form",
+		ArgsUsage: "<blueprint.json>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "list files that are not formatted instead of rewriting them",
+			},
+		},
+		Action: FormatAction,
+	}
+}
+
+func FormatAction(ctx context.Context, cmd *cli.Command) error {
+	paths := cmd.Args().Slice()
+	if len(paths) == 0 {
+		return errors.New("must provide at least one blueprint file")
+	}
+
+	check := cmd.Bool("check")
+
+	var unformatted int
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := external_ast.Format(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if bytes.Equal(data, formatted) {
+			continue
+		}
+
+		if check {
+			unformatted++
+			fmt.Fprintln(cmd.Writer, path)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("%d file(s) not formatted", unformatted)
+	}
+
+	return nil
+}
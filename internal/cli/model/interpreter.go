@@ -0,0 +1,51 @@
+package model
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/alchematik/athanor/internal/interpreter"
+
+	"github.com/urfave/cli/v3"
+)
+
+// LockFileName is the lock file that is used, next to the blueprint, if --lock-file isn't set.
+const LockFileName = "athanor.lock"
+
+// InterpreterFlags are the flags of the commands that interpret blueprints.
+func InterpreterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "allow-env",
+			Usage: "name of an environment variable that blueprints may read",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-exec",
+			Usage: "let WebAssembly blueprints and packages declare exec blueprints, which run on the host",
+		},
+		&cli.StringFlag{
+			Name:  "package-dir",
+			Usage: "path to the directory of blueprint package archives",
+		},
+		&cli.StringFlag{
+			Name:  "lock-file",
+			Usage: "path to the lock file of blueprint packages (default: athanor.lock next to the blueprint)",
+		},
+	}
+}
+
+// NewInterpreter returns the interpreter configured by InterpreterFlags for the blueprint at inputPath.
+func NewInterpreter(cmd *cli.Command, logger *slog.Logger, inputPath string) *interpreter.Interpreter {
+	lockFile := cmd.String("lock-file")
+	if lockFile == "" {
+		lockFile = filepath.Join(filepath.Dir(inputPath), LockFileName)
+	}
+
+	return &interpreter.Interpreter{
+		Logger:     logger,
+		AllowedEnv: cmd.StringSlice("allow-env"),
+		AllowExec:  cmd.Bool("allow-exec"),
+		PackageDir: cmd.String("package-dir"),
+		LockFile:   lockFile,
+	}
+}
@@ -20,7 +20,7 @@ import (
 func NewStateCommand() *cli.Command {
 	return &cli.Command{
 		Name: "state",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "log-file",
 				Usage: "path to file to write logs to",
@@ -29,7 +29,7 @@ func NewStateCommand() *cli.Command {
 				Name:  "config",
 				Usage: "path to config file",
 			},
-		},
+		}, model.InterpreterFlags()...),
 	}
 }
 
@@ -42,20 +42,29 @@ func StateAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	in := model.NewInterpreter(cmd, m.Logger, inputPath)
+	defer in.Close()
+
 	init := &StateInit{
-		spinner:   m.Spinner,
-		logger:    m.Logger,
-		inputPath: inputPath,
+		spinner:     m.Spinner,
+		logger:      m.Logger,
+		interpreter: in,
+		inputPath:   inputPath,
 		diff: &diff.DiffResult{
 			Resources: map[string]*diff.ResourceDiff{},
 			Builds:    map[string]*diff.BuildDiff{},
+			Data:      map[string]*diff.DataDiff{},
 			Plan: &plan.Plan{
 				Resources: map[string]*plan.ResourcePlan{},
 				Builds:    map[string]*plan.BuildPlan{},
+				Outputs:   map[string]*plan.OutputPlan{},
+				Data:      map[string]*plan.DataPlan{},
 			},
 			State: &state.State{
 				Resources: map[string]*state.ResourceState{},
 				Builds:    map[string]*state.BuildState{},
+				Outputs:   map[string]*state.OutputState{},
+				Data:      map[string]*state.DataState{},
 			},
 		},
 	}
@@ -65,17 +74,18 @@ func StateAction(ctx context.Context, cmd *cli.Command) error {
 }
 
 type StateInit struct {
-	logger    *slog.Logger
-	spinner   *spinner.Model
-	inputPath string
-	scope     *scope.Scope
-	diff      *diff.DiffResult
-	context   context.Context
+	logger      *slog.Logger
+	interpreter *interpreter.Interpreter
+	spinner     *spinner.Model
+	inputPath   string
+	scope       *scope.Scope
+	diff        *diff.DiffResult
+	context     context.Context
 }
 
 func (m *StateInit) Init() tea.Cmd {
 	m.scope = scope.NewScope()
-	in := &interpreter.Interpreter{Logger: m.logger}
+	in := m.interpreter
 	cmd := func() tea.Msg {
 		c := diff.Converter{
 			BlueprintInterpreter: in,
@@ -95,11 +105,7 @@ func (m *StateInit) Init() tea.Cmd {
 					Value: map[string]external_ast.Expr{},
 				},
 			},
-			BlueprintSource: external_ast.BlueprintSource{
-				LocalFile: external_ast.BlueprintSourceLocalFile{
-					Path: m.inputPath,
-				},
-			},
+			BlueprintSource: external_ast.SourceFromPath(m.inputPath),
 		}
 		if _, err := c.ConvertBuildStmt(m.diff, m.scope, "", b); err != nil {
 			return model.ErrorMsg{Error: err}
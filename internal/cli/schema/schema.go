@@ -0,0 +1,22 @@
+package schema
+
+import (
+	"context"
+
+	external_ast "github.com/alchematik/athanor/ast"
+
+	"github.com/urfave/cli/v3"
+)
+
+func NewSchemaCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "schema",
+		Usage:  "print the JSON Schema of the blueprint format",
+		Action: SchemaAction,
+	}
+}
+
+func SchemaAction(ctx context.Context, cmd *cli.Command) error {
+	_, err := cmd.Writer.Write(external_ast.Schema())
+	return err
+}
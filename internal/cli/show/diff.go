@@ -26,7 +26,7 @@ import (
 func NewDiffCommand() *cli.Command {
 	return &cli.Command{
 		Name: "diff",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "log-file",
 				Usage: "path to file to write logs to",
@@ -35,7 +35,7 @@ func NewDiffCommand() *cli.Command {
 				Name:  "config",
 				Usage: "path to config file",
 			},
-		},
+		}, model.InterpreterFlags()...),
 		Action: DiffAction,
 	}
 }
@@ -49,20 +49,29 @@ func DiffAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	in := model.NewInterpreter(cmd, m.Logger, inputPath)
+	defer in.Close()
+
 	init := &DiffInit{
-		spinner:   m.Spinner,
-		logger:    m.Logger,
-		inputPath: inputPath,
+		spinner:     m.Spinner,
+		logger:      m.Logger,
+		interpreter: in,
+		inputPath:   inputPath,
 		diff: &diff.DiffResult{
 			Resources: map[string]*diff.ResourceDiff{},
 			Builds:    map[string]*diff.BuildDiff{},
+			Data:      map[string]*diff.DataDiff{},
 			Plan: &plan.Plan{
 				Resources: map[string]*plan.ResourcePlan{},
 				Builds:    map[string]*plan.BuildPlan{},
+				Outputs:   map[string]*plan.OutputPlan{},
+				Data:      map[string]*plan.DataPlan{},
 			},
 			State: &state.State{
 				Resources: map[string]*state.ResourceState{},
 				Builds:    map[string]*state.BuildState{},
+				Outputs:   map[string]*state.OutputState{},
+				Data:      map[string]*state.DataState{},
 			},
 		},
 	}
@@ -72,17 +81,18 @@ func DiffAction(ctx context.Context, cmd *cli.Command) error {
 }
 
 type DiffInit struct {
-	logger    *slog.Logger
-	spinner   *spinner.Model
-	inputPath string
-	scope     *scope.Scope
-	diff      *diff.DiffResult
-	context   context.Context
+	logger      *slog.Logger
+	interpreter *interpreter.Interpreter
+	spinner     *spinner.Model
+	inputPath   string
+	scope       *scope.Scope
+	diff        *diff.DiffResult
+	context     context.Context
 }
 
 func (m *DiffInit) Init() tea.Cmd {
 	m.scope = scope.NewScope()
-	in := &interpreter.Interpreter{Logger: m.logger}
+	in := m.interpreter
 	cmd := func() tea.Msg {
 		c := diff.Converter{
 			BlueprintInterpreter: in,
@@ -102,11 +112,7 @@ func (m *DiffInit) Init() tea.Cmd {
 					Value: map[string]external_ast.Expr{},
 				},
 			},
-			BlueprintSource: external_ast.BlueprintSource{
-				LocalFile: external_ast.BlueprintSourceLocalFile{
-					Path: m.inputPath,
-				},
-			},
+			BlueprintSource: external_ast.SourceFromPath(m.inputPath),
 		}
 		if _, err := c.ConvertBuildStmt(m.diff, m.scope, "", b); err != nil {
 			return model.ErrorMsg{Error: err}
@@ -124,7 +130,11 @@ func (m *DiffInit) View() string {
 func (m *DiffInit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg.(type) {
 	case string:
-		iter := m.scope.NewIterator()
+		iter, err := m.scope.NewIterator()
+		if err != nil {
+			return m, func() tea.Msg { return model.ErrorMsg{Error: err} }
+		}
+
 		next := &DiffEval{
 			logger:  m.logger,
 			iter:    iter,
@@ -135,6 +145,7 @@ func (m *DiffInit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			evaluator: &eval.DiffEvaluator{
 				Iter:   iter,
 				Logger: m.logger,
+				Scope:  m.scope,
 			},
 		}
 		return next, next.Init()
@@ -232,6 +243,17 @@ func (s *DiffEval) addNodes(t treeprint.Tree, p *diff.DiffResult, id string) {
 		t.AddNode(s.renderResource(rs))
 	}
 
+	data := s.scope.Data(id)
+	sort.Strings(data)
+	for _, childID := range data {
+		ds, ok := p.DataLookup(childID)
+		if !ok {
+			panic("data not in diff: " + childID)
+		}
+
+		t.AddNode(s.renderData(ds))
+	}
+
 	builds := s.scope.Builds(id)
 	sort.Strings(builds)
 	for _, childID := range builds {
@@ -240,7 +262,7 @@ func (s *DiffEval) addNodes(t treeprint.Tree, p *diff.DiffResult, id string) {
 			panic("build not in state: " + id)
 		}
 
-		branch := t.AddBranch(s.renderEvalState(bs.GetEvalState()) + bs.GetName())
+		branch := t.AddBranch(s.renderEvalState(bs.GetEvalState()) + bs.GetName() + renderSource(bs.GetSource()) + renderMovedFrom(bs.MovedFrom()))
 
 		s.addNodes(branch, p, childID)
 	}
@@ -250,7 +272,7 @@ func (s *DiffEval) renderResource(r *diff.ResourceDiff) string {
 	p := r.GetProvider()
 	providerStr := fmt.Sprintf("(%s@%s)", p.Name, p.Version)
 	action := renderDiffAction(r.Action())
-	out := action + " " + s.renderEvalState(r.GetEvalState()) + r.GetName() + " " + providerStr + " " + "\n"
+	out := action + " " + s.renderEvalState(r.GetEvalState()) + r.GetName() + renderMovedFrom(r.MovedFrom()) + " " + providerStr + " " + "\n"
 	out += "    [identifier]\n"
 	out += render(r.Identifier(), 8, false)
 	configDiff := r.GetConfig()
@@ -261,6 +283,15 @@ func (s *DiffEval) renderResource(r *diff.ResourceDiff) string {
 	return out
 }
 
+func (s *DiffEval) renderData(d *diff.DataDiff) string {
+	p := d.GetProvider()
+	providerStr := fmt.Sprintf("(%s@%s)", p.Name, p.Version)
+	out := renderDiffAction(d.Action()) + " " + s.renderEvalState(d.GetEvalState()) + d.GetName() + " " + providerStr + " (read)\n"
+	out += "    [identifier]\n"
+	out += render(d.Identifier(), 8, false)
+	return out
+}
+
 func (s *DiffEval) renderDiff(d diff.Diff[any], space int) string {
 	padding := strings.Repeat(" ", space)
 	switch v := d.Diff.(type) {
@@ -289,6 +320,23 @@ func (s *DiffEval) renderDiff(d diff.Diff[any], space int) string {
 		default:
 			panic("unknown action: " + d.Action)
 		}
+	case diff.Sensitive:
+		return renderDiffAction(d.Action) + padding + sensitive
+	case diff.Literal[any]:
+		planVal := renderMaybe(plan.Maybe[any]{Value: v.Plan.Value}, 0, true)
+		stateVal := render(v.State.Value, 0, true)
+		switch d.Action {
+		case diff.ActionCreate:
+			return "+ " + padding + planVal
+		case diff.ActionDelete:
+			return "- " + padding + stateVal
+		case diff.ActionUpdate:
+			return "~ " + padding + fmt.Sprintf("%s -> %s", stateVal, planVal)
+		case diff.ActionUnknown:
+			return "? " + padding + unknown
+		default:
+			return "  " + padding + planVal
+		}
 	case diff.Literal[bool]:
 		switch d.Action {
 		case diff.ActionCreate:
@@ -323,6 +371,20 @@ func renderStringDiff(d diff.Diff[diff.Literal[string]], space int) string {
 	}
 }
 
+// renderSource shows where the blueprint of a build is read from.
+func renderSource(source string) string {
+	return " from " + source
+}
+
+// renderMovedFrom shows where a resource or build was moved from, so that moves aren't mistaken for replacements.
+func renderMovedFrom(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	return " (moved from " + id + ")"
+}
+
 func renderDiffAction(a diff.Action) string {
 	switch a {
 	case diff.ActionNoop:
@@ -335,6 +397,12 @@ func renderDiffAction(a diff.Action) string {
 		return "~ "
 	case diff.ActionUnknown:
 		return "? "
+	case diff.ActionRead:
+		return read
+	case diff.ActionReplace:
+		return "-/+"
+	case diff.ActionReplaceCreateFirst:
+		return "+/-"
 	default:
 		return "  "
 	}
@@ -24,7 +24,7 @@ import (
 func NewPlanCommand() *cli.Command {
 	return &cli.Command{
 		Name: "plan",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "log-file",
 				Usage: "path to file to write logs to",
@@ -33,7 +33,7 @@ func NewPlanCommand() *cli.Command {
 				Name:  "config",
 				Usage: "path to config file",
 			},
-		},
+		}, model.InterpreterFlags()...),
 		Action: PlanAction,
 	}
 }
@@ -47,11 +47,15 @@ func PlanAction(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	in := model.NewInterpreter(cmd, m.Logger, inputPath)
+	defer in.Close()
+
 	init := &PlanInitModel{
-		inputPath: inputPath,
-		context:   ctx,
-		spinner:   m.Spinner,
-		logger:    m.Logger,
+		inputPath:   inputPath,
+		context:     ctx,
+		spinner:     m.Spinner,
+		logger:      m.Logger,
+		interpreter: in,
 	}
 	m.Current = init
 	_, err = tea.NewProgram(m).Run()
@@ -59,12 +63,13 @@ func PlanAction(ctx context.Context, cmd *cli.Command) error {
 }
 
 type PlanInitModel struct {
-	logger    *slog.Logger
-	inputPath string
-	scope     *scope.Scope
-	plan      *plan.Plan
-	context   context.Context
-	spinner   *spinner.Model
+	logger      *slog.Logger
+	interpreter *interpreter.Interpreter
+	inputPath   string
+	scope       *scope.Scope
+	plan        *plan.Plan
+	context     context.Context
+	spinner     *spinner.Model
 }
 
 func (s *PlanInitModel) Init() tea.Cmd {
@@ -72,11 +77,13 @@ func (s *PlanInitModel) Init() tea.Cmd {
 	s.plan = &plan.Plan{
 		Resources: map[string]*plan.ResourcePlan{},
 		Builds:    map[string]*plan.BuildPlan{},
+		Outputs:   map[string]*plan.OutputPlan{},
+		Data:      map[string]*plan.DataPlan{},
 	}
 
 	return func() tea.Msg {
 		c := plan.Converter{
-			BlueprintInterpreter: &interpreter.Interpreter{Logger: s.logger},
+			BlueprintInterpreter: s.interpreter,
 			Logger:               s.logger,
 		}
 		b := external_ast.DeclareBuild{
@@ -92,11 +99,7 @@ func (s *PlanInitModel) Init() tea.Cmd {
 					Value: map[string]external_ast.Expr{},
 				},
 			},
-			BlueprintSource: external_ast.BlueprintSource{
-				LocalFile: external_ast.BlueprintSourceLocalFile{
-					Path: s.inputPath,
-				},
-			},
+			BlueprintSource: external_ast.SourceFromPath(s.inputPath),
 		}
 		if _, err := c.ConvertBuildStmt(s.plan, s.scope, "", b); err != nil {
 			return model.ErrorMsg{Error: err}
@@ -128,6 +131,17 @@ func (m *PlanEvalModel) addNodes(t treeprint.Tree, p *plan.Plan, id string) {
 		t.AddNode(m.renderResource(rs.GetEvalState(), rs.GetName(), rs))
 	}
 
+	data := m.scope.Data(id)
+	sort.Strings(data)
+	for _, childID := range data {
+		ds, ok := p.DataLookup(childID)
+		if !ok {
+			panic("data not in plan: " + childID)
+		}
+
+		t.AddNode(m.renderData(ds))
+	}
+
 	builds := m.scope.Builds(id)
 	sort.Strings(builds)
 	for _, childID := range builds {
@@ -141,16 +155,31 @@ func (m *PlanEvalModel) addNodes(t treeprint.Tree, p *plan.Plan, id string) {
 			continue
 		}
 
-		branch := t.AddBranch(m.renderEvalState(bs.GetEvalState()) + bs.GetName())
+		branch := t.AddBranch(m.renderEvalState(bs.GetEvalState()) + bs.GetName() + renderSource(bs.GetSource()))
 
 		m.addNodes(branch, p, childID)
 	}
+
+	outputs := m.scope.Outputs(id)
+	sort.Strings(outputs)
+	for _, childID := range outputs {
+		os, ok := p.Output(childID)
+		if !ok {
+			panic("output not in plan: " + childID)
+		}
+
+		t.AddNode(m.renderOutput(os))
+	}
 }
 
 func (s *PlanInitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg.(type) {
 	case string:
-		iter := s.scope.NewIterator()
+		iter, err := s.scope.NewIterator()
+		if err != nil {
+			return s, func() tea.Msg { return model.ErrorMsg{Error: err} }
+		}
+
 		next := &PlanEvalModel{
 			logger:    s.logger,
 			plan:      s.plan,
@@ -261,6 +290,36 @@ func (m *PlanEvalModel) renderResource(s plan.EvalState, name string, res *plan.
 	return out
 }
 
+// renderData marks data lookups as reads, since they are never changed.
+func (m *PlanEvalModel) renderData(d *plan.DataPlan) string {
+	var providerStr string
+	if provider, ok := d.Provider().Unwrap(); ok {
+		providerName, _ := provider.Name.Unwrap()
+		providerVersion, _ := provider.Version.Unwrap()
+		providerStr = fmt.Sprintf("(%s@%s)", providerName, providerVersion)
+	}
+
+	out := read + " " + m.renderEvalState(d.GetEvalState()) + d.GetName() + " " + providerStr + " (read)\n"
+	out += "    [identifier]\n"
+	out += renderMaybe(d.Identifier(), 8, false)
+	out += "    [attrs]\n"
+	out += renderMaybe(d.Attributes(), 8, false)
+	return out
+}
+
+func (m *PlanEvalModel) renderOutput(o *plan.OutputPlan) string {
+	out := m.renderEvalState(o.GetEvalState()) + "[output] " + o.GetName()
+
+	value := o.Value()
+	if v, ok := value.Unwrap(); ok {
+		if _, ok := v.(map[plan.Maybe[string]]plan.Maybe[any]); ok {
+			return out + "\n" + renderMaybe(value, 4, false)
+		}
+	}
+
+	return out + " = " + renderMaybe(value, 0, true)
+}
+
 func (m *PlanEvalModel) renderEvalState(es plan.EvalState) string {
 	switch es.State {
 	case "", "done":
@@ -275,7 +334,10 @@ func (m *PlanEvalModel) renderEvalState(es plan.EvalState) string {
 }
 
 const (
-	unknown = "(known after reconcile)"
+	unknown   = "(known after reconcile)"
+	sensitive = "(sensitive)"
+	null      = "null"
+	read      = "<="
 )
 
 func renderMaybeString(str plan.Maybe[string]) string {
@@ -306,6 +368,10 @@ func renderMaybe(val plan.Maybe[any], space int, inline bool) string {
 	// 	return renderMaybe(v, space, inline)
 	case string:
 		return padding + renderMaybeString(plan.ToMaybeType[string](val))
+	case nil:
+		return padding + null
+	case plan.Sensitive:
+		return padding + sensitive
 	case map[plan.Maybe[string]]plan.Maybe[any]:
 		m, ok := plan.ToMaybeType[map[plan.Maybe[string]]plan.Maybe[any]](val).Unwrap()
 		if !ok {
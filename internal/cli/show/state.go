@@ -24,7 +24,7 @@ import (
 func NewStateCommand() *cli.Command {
 	return &cli.Command{
 		Name: "state",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "log-file",
 				Usage: "path to file to write logs to",
@@ -33,7 +33,7 @@ func NewStateCommand() *cli.Command {
 				Name:  "config",
 				Usage: "path to config file",
 			},
-		},
+		}, model.InterpreterFlags()...),
 		Action: StateAction,
 	}
 }
@@ -46,15 +46,21 @@ func StateAction(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	in := model.NewInterpreter(cmd, m.Logger, inputPath)
+	defer in.Close()
+
 	init := &StateInit{
-		inputPath: inputPath,
-		context:   ctx,
-		spinner:   m.Spinner,
-		logger:    m.Logger,
-		scope:     scope.NewScope(),
+		inputPath:   inputPath,
+		context:     ctx,
+		spinner:     m.Spinner,
+		logger:      m.Logger,
+		interpreter: in,
+		scope:       scope.NewScope(),
 		state: &state.State{
 			Resources: map[string]*state.ResourceState{},
 			Builds:    map[string]*state.BuildState{},
+			Outputs:   map[string]*state.OutputState{},
+			Data:      map[string]*state.DataState{},
 		},
 	}
 	m.Current = init
@@ -63,19 +69,20 @@ func StateAction(ctx context.Context, cmd *cli.Command) error {
 }
 
 type StateInit struct {
-	logger     *slog.Logger
-	inputPath  string
-	configPath string
-	scope      *scope.Scope
-	state      *state.State
-	context    context.Context
-	spinner    *spinner.Model
+	logger      *slog.Logger
+	interpreter *interpreter.Interpreter
+	inputPath   string
+	configPath  string
+	scope       *scope.Scope
+	state       *state.State
+	context     context.Context
+	spinner     *spinner.Model
 }
 
 func (m *StateInit) Init() tea.Cmd {
 	cmd := func() tea.Msg {
 		c := state.Converter{
-			BlueprintInterpreter: &interpreter.Interpreter{Logger: m.logger},
+			BlueprintInterpreter: m.interpreter,
 		}
 		b := external_ast.DeclareBuild{
 			Name: "Build",
@@ -90,11 +97,7 @@ func (m *StateInit) Init() tea.Cmd {
 					Value: map[string]external_ast.Expr{},
 				},
 			},
-			BlueprintSource: external_ast.BlueprintSource{
-				LocalFile: external_ast.BlueprintSourceLocalFile{
-					Path: m.inputPath,
-				},
-			},
+			BlueprintSource: external_ast.SourceFromPath(m.inputPath),
 		}
 		if _, err := c.ConvertBuildStmt(m.state, m.scope, "", b); err != nil {
 			return model.ErrorMsg{Error: err}
@@ -112,7 +115,11 @@ func (m *StateInit) View() string {
 func (m *StateInit) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg.(type) {
 	case string:
-		iter := m.scope.NewIterator()
+		iter, err := m.scope.NewIterator()
+		if err != nil {
+			return m, func() tea.Msg { return model.ErrorMsg{Error: err} }
+		}
+
 		next := &StateEvalModel{
 			state:   m.state,
 			iter:    iter,
@@ -213,6 +220,17 @@ func (s *StateEvalModel) addNodes(t treeprint.Tree, p *state.State, id string) {
 		t.AddNode(s.renderResource(rs.GetEvalState(), rs.GetName(), rs))
 	}
 
+	data := s.scope.Data(id)
+	sort.Strings(data)
+	for _, childID := range data {
+		ds, ok := p.DataLookup(childID)
+		if !ok {
+			panic("data not in state: " + childID)
+		}
+
+		t.AddNode(s.renderData(ds))
+	}
+
 	builds := s.scope.Builds(id)
 	sort.Strings(builds)
 	for _, childID := range builds {
@@ -226,10 +244,44 @@ func (s *StateEvalModel) addNodes(t treeprint.Tree, p *state.State, id string) {
 			continue
 		}
 
-		branch := t.AddBranch(s.renderEvalState(bs.GetEvalState()) + bs.GetName())
+		branch := t.AddBranch(s.renderEvalState(bs.GetEvalState()) + bs.GetName() + renderSource(bs.GetSource()))
 
 		s.addNodes(branch, p, childID)
 	}
+
+	outputs := s.scope.Outputs(id)
+	sort.Strings(outputs)
+	for _, childID := range outputs {
+		os, ok := p.Output(childID)
+		if !ok {
+			panic("output not in state: " + childID)
+		}
+
+		t.AddNode(s.renderOutput(os))
+	}
+}
+
+func (s *StateEvalModel) renderData(d *state.DataState) string {
+	p := d.Provider()
+	providerStr := fmt.Sprintf("(%s@%s)", p.Name, p.Version)
+
+	out := read + " " + s.renderEvalState(d.GetEvalState()) + d.GetName() + " " + providerStr + " (read)\n"
+	out += "    [identifier]\n"
+	out += render(d.Identifier(), 8, false)
+	out += "    [attrs]\n"
+	out += render(d.Attributes(), 8, false)
+	return out
+}
+
+func (s *StateEvalModel) renderOutput(o *state.OutputState) string {
+	out := s.renderEvalState(o.GetEvalState()) + "[output] " + o.GetName()
+
+	value := o.Value()
+	if _, ok := value.(map[string]any); ok {
+		return out + "\n" + render(value, 4, false)
+	}
+
+	return out + " = " + render(value, 0, true)
 }
 
 func (s *StateEvalModel) renderEvalState(es state.EvalState) string {
@@ -271,6 +323,10 @@ func render(val any, space int, inline bool) string {
 	switch val := val.(type) {
 	case string:
 		return padding + renderString(val)
+	case nil:
+		return padding + null
+	case state.Sensitive:
+		return padding + sensitive
 	case map[string]any:
 		var list [][]string
 		for k, v := range val {
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	external_ast "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/cli/model"
+
+	"github.com/urfave/cli/v3"
+)
+
+func NewValidateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "check a blueprint and every blueprint it builds for errors",
+		ArgsUsage: "<blueprint>",
+		Flags:     model.InterpreterFlags(),
+		Action:    ValidateAction,
+	}
+}
+
+func ValidateAction(ctx context.Context, cmd *cli.Command) error {
+	inputPath := cmd.Args().First()
+	if inputPath == "" {
+		return errors.New("must provide a path to a blueprint")
+	}
+
+	logger := slog.New(slog.NewTextHandler(cmd.ErrWriter, nil))
+	in := model.NewInterpreter(cmd, logger, inputPath)
+	defer in.Close()
+
+	v := external_ast.Validator{BlueprintInterpreter: in}
+	b := external_ast.DeclareBuild{
+		Name: "Build",
+		Exists: external_ast.Expr{
+			Type: "bool",
+			Value: external_ast.BoolLiteral{
+				Value: true,
+			},
+		},
+		Runtimeinput: external_ast.Expr{
+			Type: "map",
+			Value: external_ast.MapCollection{
+				Value: map[string]external_ast.Expr{},
+			},
+		},
+		BlueprintSource: external_ast.SourceFromPath(inputPath),
+	}
+
+	err := v.Validate("", b)
+	var errs external_ast.ValidationErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			fmt.Fprintln(cmd.Writer, e)
+		}
+
+		return fmt.Errorf("found %d problem(s)", len(errs))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.Writer, "blueprint is valid")
+	return nil
+}
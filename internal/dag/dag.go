@@ -1,8 +1,10 @@
 package dag
 
 import (
-	// "errors"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/alchematik/athanor/internal/set"
@@ -21,9 +23,9 @@ type Graph struct {
 }
 
 func (g *Graph) AddEdge(from, to string) error {
-	// if from == "" || to == "" {
-	// 	return errors.New("node names must not be empty")
-	// }
+	if from == "" || to == "" {
+		return errors.New("node names must not be empty")
+	}
 
 	forward, ok := g.forwardEdges[from]
 	if !ok {
@@ -50,6 +52,74 @@ func (g *Graph) AddEdge(from, to string) error {
 	return nil
 }
 
+// CycleError is returned for graphs with a cycle, which would never be iterated over.
+type CycleError struct {
+	// Path is the nodes in the cycle, starting and ending with the same node.
+	Path []string
+}
+
+func (e CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// CheckCycles returns a CycleError for the first cycle in the graph. Nodes are visited in order, so that the
+// same cycle is reported every time.
+func (g *Graph) CheckCycles() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	nodes := make([]string, 0, len(g.forwardEdges))
+	for n := range g.forwardEdges {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	state := map[string]int{}
+	var path []string
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		state[n] = visiting
+		path = append(path, n)
+
+		next := g.forwardEdges[n].Values()
+		sort.Strings(next)
+		for _, e := range next {
+			switch state[e] {
+			case visiting:
+				for i, p := range path {
+					if p == e {
+						return append(append([]string{}, path[i:]...), e)
+					}
+				}
+			case done:
+				continue
+			default:
+				if cycle := visit(e); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[n] = done
+		return nil
+	}
+
+	for _, n := range nodes {
+		if state[n] != 0 {
+			continue
+		}
+
+		if cycle := visit(n); cycle != nil {
+			return CycleError{Path: cycle}
+		}
+	}
+
+	return nil
+}
+
 type Iterator struct {
 	sync.Mutex
 
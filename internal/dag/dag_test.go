@@ -263,3 +263,57 @@ func TestIteratorRevisit(t *testing.T) {
 	require.True(t, iter.Visited("a"))
 	require.NoError(t, iter.Done("a"))
 }
+
+func TestGraph_AddEdgeEmpty(t *testing.T) {
+	g := dag.NewGraph()
+	require.EqualError(t, g.AddEdge("", "a"), "node names must not be empty")
+	require.EqualError(t, g.AddEdge("a", ""), "node names must not be empty")
+	require.NoError(t, g.CheckCycles())
+}
+
+func TestGraph_CheckCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+		cycle []string
+	}{
+		{
+			name:  "no cycle",
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}},
+		},
+		{
+			name:  "self",
+			edges: [][2]string{{"a", "a"}},
+			cycle: []string{"a", "a"},
+		},
+		{
+			name:  "two nodes",
+			edges: [][2]string{{".Build", ".Build.a"}, {".Build", ".Build.b"}, {".Build.a", ".Build.b"}, {".Build.b", ".Build.a"}},
+			cycle: []string{".Build.a", ".Build.b", ".Build.a"},
+		},
+		{
+			name:  "behind other nodes",
+			edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "d"}, {"d", "b"}},
+			cycle: []string{"b", "c", "d", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := dag.NewGraph()
+			for _, e := range test.edges {
+				require.NoError(t, g.AddEdge(e[0], e[1]))
+			}
+
+			err := g.CheckCycles()
+			if test.cycle == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var cycleErr dag.CycleError
+			require.ErrorAs(t, err, &cycleErr)
+			require.Equal(t, test.cycle, cycleErr.Path)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	external "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/parallel"
 	"github.com/alchematik/athanor/internal/plan"
 	"github.com/alchematik/athanor/internal/scope"
 	"github.com/alchematik/athanor/internal/state"
@@ -13,52 +14,88 @@ type Converter struct {
 	BlueprintInterpreter BlueprintInterpreter
 	PlanConverter        *plan.Converter
 	StateConverter       *state.Converter
+	// Interpreting bounds how many blueprints are interpreted at once while sub-builds are converted in
+	// parallel.
+	Interpreting parallel.Limiter
 }
 
 type BlueprintInterpreter interface {
 	InterpretBlueprint(source external.BlueprintSource, input map[string]any) (external.Blueprint, error)
 }
 
+func isBuild(stmt external.Stmt) bool {
+	_, ok := stmt.Value.(external.DeclareBuild)
+	return ok
+}
+
 func (c *Converter) ConvertStmt(d *DiffResult, sc *scope.Scope, parentID string, stmt external.Stmt) (any, error) {
 	switch stmt := stmt.Value.(type) {
 	case external.DeclareResource:
 		return c.ConvertResourceStmt(d, sc, parentID, stmt)
 	case external.DeclareBuild:
 		return c.ConvertBuildStmt(d, sc, parentID, stmt)
+	case external.DeclareData:
+		return c.ConvertDataStmt(d, sc, parentID, stmt)
+	case external.DeclareOutput:
+		return c.ConvertOutputStmt(d, sc, parentID, stmt)
+	case external.DeclareMoved:
+		return sc.Move(parentID, stmt.From, stmt.To), nil
 	default:
 		return nil, fmt.Errorf("unsupported statement type: %T", stmt)
 	}
 }
 
 func (c *Converter) ConvertBuildStmt(d *DiffResult, sc *scope.Scope, parentID string, stmt external.DeclareBuild) (StmtBuild, error) {
-	blueprint, err := c.BlueprintInterpreter.InterpretBlueprint(stmt.BlueprintSource, stmt.Input)
+	var blueprint external.Blueprint
+	var err error
+	c.Interpreting.Do(func() {
+		blueprint, stmt, err = external.InterpretBuild(c.BlueprintInterpreter, stmt)
+	})
 	if err != nil {
 		return StmtBuild{}, err
 	}
 
-	planRuntimeInput, err := c.PlanConverter.ConvertMapExpr(stmt.Name, stmt.Runtimeinput)
+	id := fmt.Sprintf("%s.%s", parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: id}
+
+	if errs := external.CheckInputs(id, stmt, blueprint); len(errs) > 0 {
+		return StmtBuild{}, errs
+	}
+
+	for _, address := range stmt.DependsOn {
+		owner.DependOnAddress(address)
+	}
+
+	planRuntimeInput, err := c.PlanConverter.ConvertMapExpr(owner, stmt.Runtimeinput)
 	if err != nil {
 		return StmtBuild{}, err
 	}
 
-	stateRuntimeInput, err := c.StateConverter.ConvertMapExpr(stmt.Name, stmt.Runtimeinput)
+	stateRuntimeInput, err := c.StateConverter.ConvertMapExpr(owner, stmt.Runtimeinput)
 	if err != nil {
 		return StmtBuild{}, err
 	}
+	source := stmt.BlueprintSource.String()
+	d.State.SetBuild(id, state.NewBuildState(stmt.Name, source))
+	d.Plan.SetBuild(id, plan.NewBuildPlan(stmt.Name, source))
+	d.SetBuild(id, &BuildDiff{name: stmt.Name, source: source})
 
-	id := fmt.Sprintf("%s.%s", parentID, stmt.Name)
-	d.State.Builds[id] = state.NewBuildState(stmt.Name)
-	d.Plan.Builds[id] = plan.NewBuildPlan(stmt.Name)
-	d.Builds[id] = &BuildDiff{name: stmt.Name}
-
-	var stmts []any
-	for _, s := range blueprint.Stmts {
-		converted, err := c.ConvertStmt(d, sc, id, s)
+	var instances []external.Stmt
+	for _, stmt := range blueprint.Stmts {
+		expanded, err := external.Expand(stmt)
 		if err != nil {
-			return StmtBuild{}, err
+			return StmtBuild{}, fmt.Errorf("%s.%s", id, err)
 		}
 
-		stmts = append(stmts, converted)
+		instances = append(instances, expanded...)
+	}
+
+	// Sub-builds are converted in parallel, since interpreting their blueprints is slow.
+	stmts, err := parallel.Map(instances, isBuild, func(stmt external.Stmt) (any, error) {
+		return c.ConvertStmt(d, sc, id, stmt)
+	})
+	if err != nil {
+		return StmtBuild{}, err
 	}
 	b := StmtBuild{
 		ID:                id,
@@ -74,47 +111,51 @@ func (c *Converter) ConvertBuildStmt(d *DiffResult, sc *scope.Scope, parentID st
 
 func (c *Converter) ConvertResourceStmt(d *DiffResult, sc *scope.Scope, parentID string, stmt external.DeclareResource) (StmtResource, error) {
 	resourceID := fmt.Sprintf("%s.%s", parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: resourceID}
+	for _, address := range stmt.DependsOn {
+		owner.DependOnAddress(address)
+	}
 
-	d.Plan.Resources[resourceID] = plan.NewResourcePlan(stmt.Name)
-	d.State.Resources[resourceID] = state.NewResourceState(stmt.Name)
-	d.Resources[resourceID] = &ResourceDiff{name: stmt.Name}
+	d.Plan.SetResource(resourceID, plan.NewResourcePlan(stmt.Name))
+	d.State.SetResource(resourceID, state.NewResourceState(stmt.Name))
+	d.SetResource(resourceID, &ResourceDiff{name: stmt.Name})
 
-	t, err := c.StateConverter.ConvertStringExpr(stmt.Name, stmt.Type)
+	t, err := c.StateConverter.ConvertStringExpr(owner, stmt.Type)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	id, err := c.StateConverter.ConvertAnyExpr(stmt.Name, stmt.Identifier)
+	id, err := c.StateConverter.ConvertAnyExpr(owner, stmt.Identifier)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	provider, err := c.StateConverter.ConvertProviderExpr(stmt.Name, stmt.Provider)
+	provider, err := c.StateConverter.ConvertProviderExpr(owner, stmt.Provider)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	planExists, err := c.PlanConverter.ConvertBoolExpr(stmt.Name, stmt.Exists)
+	planExists, err := c.PlanConverter.ConvertBoolExpr(owner, stmt.Exists)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	planType, err := c.PlanConverter.ConvertStringExpr(stmt.Name, stmt.Type)
+	planType, err := c.PlanConverter.ConvertStringExpr(owner, stmt.Type)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	planProvider, err := c.PlanConverter.ConvertProviderExpr(stmt.Name, stmt.Provider)
+	planProvider, err := c.PlanConverter.ConvertProviderExpr(owner, stmt.Provider)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	planIdentifier, err := c.PlanConverter.ConvertAnyExpr(stmt.Name, stmt.Identifier)
+	planIdentifier, err := c.PlanConverter.ConvertAnyExpr(owner, stmt.Identifier)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	planConfig, err := c.PlanConverter.ConvertAnyExpr(stmt.Name, stmt.Config)
+	planConfig, err := c.PlanConverter.ConvertAnyExpr(owner, stmt.Config)
 	if err != nil {
 		return StmtResource{}, err
 	}
@@ -133,12 +174,86 @@ func (c *Converter) ConvertResourceStmt(d *DiffResult, sc *scope.Scope, parentID
 		PlanProvider:   planProvider,
 		PlanIdentifier: planIdentifier,
 		PlanConfig:     planConfig,
+
+		Lifecycle: Lifecycle{
+			PreventDestroy:      stmt.Lifecycle.PreventDestroy,
+			IgnoreChanges:       stmt.Lifecycle.IgnoreChanges,
+			CreateBeforeDestroy: stmt.Lifecycle.CreateBeforeDestroy,
+		},
 	}
 	sc.SetResource(parentID, resourceID, sr)
 
 	return sr, nil
 }
 
+// ConvertDataStmt only converts the state side of a data lookup. Whatever is read is used for both the plan and
+// the state, since a data lookup is never changed.
+func (c *Converter) ConvertDataStmt(d *DiffResult, sc *scope.Scope, parentID string, stmt external.DeclareData) (StmtData, error) {
+	dataID := fmt.Sprintf("%s.%s", parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: dataID}
+
+	d.Plan.SetData(dataID, plan.NewDataPlan(stmt.Name))
+	d.State.SetData(dataID, state.NewDataState(stmt.Name))
+	d.SetData(dataID, &DataDiff{name: stmt.Name})
+
+	t, err := c.StateConverter.ConvertStringExpr(owner, stmt.Type)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	provider, err := c.StateConverter.ConvertProviderExpr(owner, stmt.Provider)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	id, err := c.StateConverter.ConvertAnyExpr(owner, stmt.Identifier)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	sd := StmtData{
+		ID:      dataID,
+		Name:    stmt.Name,
+		BuildID: parentID,
+
+		Type:       t,
+		Provider:   provider,
+		Identifier: id,
+	}
+	sc.SetData(parentID, dataID, sd)
+
+	return sd, nil
+}
+
+func (c *Converter) ConvertOutputStmt(d *DiffResult, sc *scope.Scope, parentID string, stmt external.DeclareOutput) (StmtOutput, error) {
+	outputID := scope.OutputID(parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: outputID}
+
+	d.Plan.SetOutput(outputID, plan.NewOutputPlan(stmt.Name))
+	d.State.SetOutput(outputID, state.NewOutputState(stmt.Name))
+
+	planValue, err := c.PlanConverter.ConvertAnyExpr(owner, stmt.Value)
+	if err != nil {
+		return StmtOutput{}, err
+	}
+
+	stateValue, err := c.StateConverter.ConvertAnyExpr(owner, stmt.Value)
+	if err != nil {
+		return StmtOutput{}, err
+	}
+
+	o := StmtOutput{
+		ID:         outputID,
+		Name:       stmt.Name,
+		BuildID:    parentID,
+		PlanValue:  planValue,
+		StateValue: stateValue,
+	}
+	sc.SetOutput(parentID, outputID, o)
+
+	return o, nil
+}
+
 // func (c *Converter) ConvertAnyExpr(name string, expr external.Expr) (Expr[any], error) {
 // 	switch expr.Value.(type) {
 // 	case external.BoolLiteral:
@@ -15,6 +15,7 @@ type DiffResult struct {
 	State     *state.State
 	Resources map[string]*ResourceDiff
 	Builds    map[string]*BuildDiff
+	Data      map[string]*DataDiff
 }
 
 func (d *DiffResult) Resource(id string) (*ResourceDiff, bool) {
@@ -33,6 +34,35 @@ func (d *DiffResult) Build(id string) (*BuildDiff, bool) {
 	return b, ok
 }
 
+func (d *DiffResult) DataLookup(id string) (*DataDiff, bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	data, ok := d.Data[id]
+	return data, ok
+}
+
+func (d *DiffResult) SetResource(id string, resource *ResourceDiff) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.Resources[id] = resource
+}
+
+func (d *DiffResult) SetBuild(id string, build *BuildDiff) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.Builds[id] = build
+}
+
+func (d *DiffResult) SetData(id string, data *DataDiff) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.Data[id] = data
+}
+
 type EvalState struct {
 	State string
 	Error error
@@ -43,8 +73,34 @@ type BuildDiff struct {
 
 	action    Action
 	name      string
+	source    string
 	evalState EvalState
 	exists    Diff[Literal[bool]]
+	movedFrom string
+}
+
+// GetSource is where the blueprint of the build is read from, with its path resolved.
+func (b *BuildDiff) GetSource() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.source
+}
+
+// SetMovedFrom records the ID the build had before it was moved.
+func (b *BuildDiff) SetMovedFrom(id string) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.movedFrom = id
+}
+
+// MovedFrom is the ID the build had before it was moved, if it was.
+func (b *BuildDiff) MovedFrom() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.movedFrom
 }
 
 func (b *BuildDiff) ToDone() {
@@ -89,6 +145,85 @@ func (b *BuildDiff) SetExists(exists Diff[Literal[bool]]) {
 	b.exists = exists
 }
 
+// DataDiff is a data lookup. It is only ever read.
+type DataDiff struct {
+	sync.Mutex
+
+	name      string
+	evalState EvalState
+
+	identifier any
+	provider   state.Provider
+}
+
+func (d *DataDiff) GetName() string {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.name
+}
+
+func (d *DataDiff) Action() Action {
+	return ActionRead
+}
+
+func (d *DataDiff) Identifier() any {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.identifier
+}
+
+func (d *DataDiff) SetIdentifier(id any) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.identifier = id
+}
+
+func (d *DataDiff) GetProvider() state.Provider {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.provider
+}
+
+func (d *DataDiff) SetProvider(p state.Provider) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.provider = p
+}
+
+func (d *DataDiff) GetEvalState() EvalState {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.evalState
+}
+
+func (d *DataDiff) ToError(err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "error"
+	d.evalState.Error = err
+}
+
+func (d *DataDiff) ToDone() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "done"
+}
+
+func (d *DataDiff) ToEvaluating() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "evaluating"
+}
+
 type ResourceDiff struct {
 	sync.Mutex
 
@@ -102,6 +237,24 @@ type ResourceDiff struct {
 
 	exists Diff[Literal[bool]]
 	config Diff[any]
+
+	movedFrom string
+}
+
+// SetMovedFrom records the ID the resource had before it was moved.
+func (r *ResourceDiff) SetMovedFrom(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.movedFrom = id
+}
+
+// MovedFrom is the ID the resource had before it was moved, if it was.
+func (r *ResourceDiff) MovedFrom() string {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.movedFrom
 }
 
 func (r *ResourceDiff) SetExists(exists Diff[Literal[bool]]) {
@@ -207,11 +360,19 @@ type Action string
 
 const (
 	ActionCreate  Action = "create"
-	ActionUpdate         = "update"
-	ActionDelete         = "delete"
-	ActionNoop           = "noop"
-	ActionUnknown        = "unknown"
-	ActionEmpty          = ""
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionNoop    Action = "noop"
+	ActionUnknown Action = "unknown"
+	ActionEmpty   Action = ""
+
+	// ActionRead is only used for data lookups, which are never changed.
+	ActionRead Action = "read"
+
+	// ActionReplace deletes a resource and then creates it again, for changes that can't be made in place.
+	ActionReplace Action = "replace"
+	// ActionReplaceCreateFirst creates the new resource before deleting the old one.
+	ActionReplaceCreateFirst Action = "replace_create_first"
 )
 
 type Diff[T any] struct {
@@ -378,14 +539,6 @@ func DiffMap(p Emptyable[plan.Maybe[map[plan.Maybe[string]]plan.Maybe[any]]], s
 				continue
 			}
 
-			kd = Diff[Literal[string]]{
-				Action: ActionDelete,
-				Diff: Literal[string]{
-					Plan:  Emptyable[string]{IsEmpty: true},
-					State: Emptyable[string]{Value: k},
-				},
-			}
-
 			vd, err := DiffAny(
 				Emptyable[plan.Maybe[any]]{IsEmpty: true},
 				Emptyable[any]{Value: v},
@@ -394,6 +547,20 @@ func DiffMap(p Emptyable[plan.Maybe[map[plan.Maybe[string]]plan.Maybe[any]]], s
 				return Diff[Map]{}, err
 			}
 
+			// Null fields that the blueprint doesn't set are left as they are.
+			if vd.Action == ActionNoop {
+				m[kd] = vd
+				continue
+			}
+
+			kd = Diff[Literal[string]]{
+				Action: ActionDelete,
+				Diff: Literal[string]{
+					Plan:  Emptyable[string]{IsEmpty: true},
+					State: Emptyable[string]{Value: k},
+				},
+			}
+
 			isUpdate = true
 			m[kd] = vd
 		}
@@ -415,6 +582,14 @@ func DiffAny(p Emptyable[plan.Maybe[any]], s Emptyable[any]) (Diff[any], error)
 		return Diff[any]{Action: ActionNoop}, nil
 	}
 
+	if isSensitive(p, s) {
+		return DiffSensitive(p, s)
+	}
+
+	if isNull(p, s) {
+		return DiffNull(p, s)
+	}
+
 	if p.IsEmpty {
 		switch val := s.Value.(type) {
 		case string:
@@ -476,6 +651,7 @@ func DiffAny(p Emptyable[plan.Maybe[any]], s Emptyable[any]) (Diff[any], error)
 			Action: d.Action,
 		}, nil
 	case bool:
+		stateBool, _ := s.Value.(bool)
 		d, err := DiffLiteral[bool](
 			Emptyable[plan.Maybe[bool]]{
 				IsEmpty: p.IsEmpty,
@@ -486,7 +662,7 @@ func DiffAny(p Emptyable[plan.Maybe[any]], s Emptyable[any]) (Diff[any], error)
 			},
 			Emptyable[bool]{
 				IsEmpty: s.IsEmpty,
-				Value:   s.Value.(bool),
+				Value:   stateBool,
 			},
 		)
 		if err != nil {
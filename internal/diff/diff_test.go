@@ -0,0 +1,285 @@
+package diff_test
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/internal/diff"
+	"github.com/alchematik/athanor/internal/plan"
+	"github.com/alchematik/athanor/internal/state"
+)
+
+func TestDiffAny_Sensitive(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     diff.Emptyable[plan.Maybe[any]]
+		state    diff.Emptyable[any]
+		expected diff.Action
+	}{
+		{
+			name:     "unchanged",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: plan.Sensitive{Value: "hunter2"}}},
+			state:    diff.Emptyable[any]{Value: state.Sensitive{Value: "hunter2"}},
+			expected: diff.ActionNoop,
+		},
+		{
+			name:     "changed",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: plan.Sensitive{Value: "hunter3"}}},
+			state:    diff.Emptyable[any]{Value: state.Sensitive{Value: "hunter2"}},
+			expected: diff.ActionUpdate,
+		},
+		{
+			name:     "marked by provider only",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: "hunter2"}},
+			state:    diff.Emptyable[any]{Value: state.Sensitive{Value: "hunter2"}},
+			expected: diff.ActionNoop,
+		},
+		{
+			name: "map",
+			plan: diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: plan.Sensitive{
+				Value: map[plan.Maybe[string]]plan.Maybe[any]{
+					{Value: "user"}: {Value: "admin"},
+				},
+			}}},
+			state: diff.Emptyable[any]{Value: state.Sensitive{
+				Value: map[string]any{"user": "admin"},
+			}},
+			expected: diff.ActionNoop,
+		},
+		{
+			name:     "unknown",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: plan.Sensitive{}, Unknown: true}},
+			state:    diff.Emptyable[any]{Value: state.Sensitive{Value: "hunter2"}},
+			expected: diff.ActionUnknown,
+		},
+		{
+			name:     "create",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: plan.Sensitive{Value: "hunter2"}}},
+			state:    diff.Emptyable[any]{IsEmpty: true},
+			expected: diff.ActionCreate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := diff.DiffAny(test.plan, test.state)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, d.Action)
+
+			s, ok := d.Diff.(diff.Sensitive)
+			require.True(t, ok)
+			require.Equal(t, "(sensitive)", fmt.Sprintf("%v", s))
+			require.NotContains(t, fmt.Sprintf("%+v %#v", s, s), "hunter")
+
+			var out strings.Builder
+			slog.New(slog.NewTextHandler(&out, nil)).Info("diff", "config", s)
+			require.NotContains(t, out.String(), "hunter")
+		})
+	}
+}
+
+func TestDiffAny_Null(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     diff.Emptyable[plan.Maybe[any]]
+		state    diff.Emptyable[any]
+		expected diff.Action
+	}{
+		{
+			name:     "both null",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: nil}},
+			state:    diff.Emptyable[any]{Value: nil},
+			expected: diff.ActionNoop,
+		},
+		{
+			name:     "clear value",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: nil}},
+			state:    diff.Emptyable[any]{Value: "foo"},
+			expected: diff.ActionUpdate,
+		},
+		{
+			name:     "set value",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: true}},
+			state:    diff.Emptyable[any]{Value: nil},
+			expected: diff.ActionUpdate,
+		},
+		{
+			name:     "null in state only",
+			plan:     diff.Emptyable[plan.Maybe[any]]{IsEmpty: true},
+			state:    diff.Emptyable[any]{Value: nil},
+			expected: diff.ActionNoop,
+		},
+		{
+			name:     "unknown",
+			plan:     diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Unknown: true}},
+			state:    diff.Emptyable[any]{Value: nil},
+			expected: diff.ActionUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := diff.DiffAny(test.plan, test.state)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, d.Action)
+		})
+	}
+}
+
+func TestDiffMap_NullFromProvider(t *testing.T) {
+	d, err := diff.DiffMap(
+		diff.Emptyable[plan.Maybe[map[plan.Maybe[string]]plan.Maybe[any]]]{
+			Value: plan.Maybe[map[plan.Maybe[string]]plan.Maybe[any]]{
+				Value: map[plan.Maybe[string]]plan.Maybe[any]{
+					{Value: "name"}: {Value: "foo"},
+				},
+			},
+		},
+		diff.Emptyable[map[string]any]{
+			Value: map[string]any{"name": "foo", "labels": nil},
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, diff.ActionNoop, d.Action)
+}
+
+func TestLifecycle_Action(t *testing.T) {
+	update := diff.Diff[any]{Action: diff.ActionUpdate}
+	tests := []struct {
+		name        string
+		lifecycle   diff.Lifecycle
+		planExists  plan.Maybe[bool]
+		stateExists bool
+		replace     bool
+		expected    diff.Action
+		err         string
+	}{
+		{
+			name:        "create",
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: false,
+			expected:    diff.ActionCreate,
+		},
+		{
+			name:        "delete",
+			planExists:  plan.Maybe[bool]{Value: false},
+			stateExists: true,
+			expected:    diff.ActionDelete,
+		},
+		{
+			name:        "already gone",
+			planExists:  plan.Maybe[bool]{Value: false},
+			stateExists: false,
+			expected:    diff.ActionNoop,
+		},
+		{
+			name:        "update",
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: true,
+			expected:    diff.ActionUpdate,
+		},
+		{
+			name:        "unknown",
+			planExists:  plan.Maybe[bool]{Unknown: true},
+			stateExists: true,
+			expected:    diff.ActionUnknown,
+		},
+		{
+			name:        "replace",
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: true,
+			replace:     true,
+			expected:    diff.ActionReplace,
+		},
+		{
+			name:        "create before destroy",
+			lifecycle:   diff.Lifecycle{CreateBeforeDestroy: true},
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: true,
+			replace:     true,
+			expected:    diff.ActionReplaceCreateFirst,
+		},
+		{
+			name:        "protected delete",
+			lifecycle:   diff.Lifecycle{PreventDestroy: true},
+			planExists:  plan.Maybe[bool]{Value: false},
+			stateExists: true,
+			expected:    diff.ActionDelete,
+			err:         "prevent_destroy is set but the resource would be deleted",
+		},
+		{
+			name:        "protected replace",
+			lifecycle:   diff.Lifecycle{PreventDestroy: true},
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: true,
+			replace:     true,
+			expected:    diff.ActionReplace,
+			err:         "prevent_destroy is set but the resource would be replaced",
+		},
+		{
+			name:        "protected update",
+			lifecycle:   diff.Lifecycle{PreventDestroy: true},
+			planExists:  plan.Maybe[bool]{Value: true},
+			stateExists: true,
+			expected:    diff.ActionUpdate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action, err := test.lifecycle.Action(test.planExists, test.stateExists, update, test.replace)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, action)
+		})
+	}
+}
+
+func TestIgnoreChanges(t *testing.T) {
+	planned := plan.Maybe[any]{Value: map[plan.Maybe[string]]plan.Maybe[any]{
+		{Value: "location"}: {Value: "us-east1"},
+		{Value: "labels"}: {Value: map[plan.Maybe[string]]plan.Maybe[any]{
+			{Value: "env"}:  {Value: "prod"},
+			{Value: "team"}: {Value: "infra"},
+		}},
+	}}
+	current := map[string]any{
+		"location": "us-east1",
+		"labels":   map[string]any{"env": "staging", "team": "infra", "owner": "ops"},
+	}
+
+	d, err := diff.DiffAny(
+		diff.Emptyable[plan.Maybe[any]]{Value: diff.IgnoreChanges(planned, current, []string{"labels.env", "labels.owner"})},
+		diff.Emptyable[any]{Value: current},
+	)
+	require.NoError(t, err)
+	require.Equal(t, diff.ActionNoop, d.Action)
+
+	d, err = diff.DiffAny(
+		diff.Emptyable[plan.Maybe[any]]{Value: diff.IgnoreChanges(planned, current, []string{"labels.owner"})},
+		diff.Emptyable[any]{Value: current},
+	)
+	require.NoError(t, err)
+	require.Equal(t, diff.ActionUpdate, d.Action)
+}
+
+func TestRequiresReplace(t *testing.T) {
+	d, err := diff.DiffAny(
+		diff.Emptyable[plan.Maybe[any]]{Value: plan.Maybe[any]{Value: map[plan.Maybe[string]]plan.Maybe[any]{
+			{Value: "location"}: {Value: "eu-west1"},
+			{Value: "class"}:    {Value: "standard"},
+		}}},
+		diff.Emptyable[any]{Value: map[string]any{"location": "us-east1", "class": "standard"}},
+	)
+	require.NoError(t, err)
+
+	require.True(t, diff.RequiresReplace(d, map[string]bool{"location": true}))
+	require.False(t, diff.RequiresReplace(d, map[string]bool{"class": true}))
+}
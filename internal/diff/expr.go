@@ -29,4 +29,25 @@ type StmtResource struct {
 	PlanProvider   plan.Expr[plan.Provider]
 	PlanIdentifier plan.Expr[any]
 	PlanConfig     plan.Expr[any]
+
+	Lifecycle Lifecycle
+}
+
+type StmtOutput struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	PlanValue  plan.Expr[any]
+	StateValue state.Expr[any]
+}
+
+type StmtData struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	Type       state.Expr[string]
+	Provider   state.Expr[state.Provider]
+	Identifier state.Expr[any]
 }
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alchematik/athanor/internal/plan"
+	"github.com/alchematik/athanor/internal/state"
+)
+
+// Lifecycle controls how changes to a resource are carried out.
+type Lifecycle struct {
+	PreventDestroy      bool
+	IgnoreChanges       []string
+	CreateBeforeDestroy bool
+}
+
+// Action decides what reconciling a resource does, given whether it should exist, whether it exists now, the
+// diff of its config and whether that diff touches attributes that can't be changed in place. Actions that
+// would delete a resource protected by PreventDestroy are errors.
+func (l Lifecycle) Action(planExists plan.Maybe[bool], stateExists bool, config Diff[any], replace bool) (Action, error) {
+	exists, ok := planExists.Unwrap()
+	if !ok {
+		return ActionUnknown, nil
+	}
+
+	switch {
+	case !exists && !stateExists:
+		return ActionNoop, nil
+	case exists && !stateExists:
+		return ActionCreate, nil
+	case !exists && stateExists:
+		if l.PreventDestroy {
+			return ActionDelete, fmt.Errorf("prevent_destroy is set but the resource would be deleted")
+		}
+
+		return ActionDelete, nil
+	}
+
+	if !replace {
+		return config.Action, nil
+	}
+
+	action := ActionReplace
+	if l.CreateBeforeDestroy {
+		action = ActionReplaceCreateFirst
+	}
+
+	if l.PreventDestroy {
+		return action, fmt.Errorf("prevent_destroy is set but the resource would be replaced")
+	}
+
+	return action, nil
+}
+
+// RequiresReplace reports whether any of the top level config keys in keys changed.
+func RequiresReplace(config Diff[any], keys map[string]bool) bool {
+	m, ok := config.Diff.(Map)
+	if !ok {
+		return false
+	}
+
+	for kd, vd := range m {
+		key := kd.Diff.Plan.Value
+		if kd.Diff.Plan.IsEmpty {
+			key = kd.Diff.State.Value
+		}
+
+		if keys[key] && (kd.Action != ActionNoop || vd.Action != ActionNoop) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IgnoreChanges replaces the values at paths in the planned config with what is in the state, so that changes
+// to them diff as no-ops.
+func IgnoreChanges(p plan.Maybe[any], s any, paths []string) plan.Maybe[any] {
+	for _, path := range paths {
+		p = ignorePath(p, s, strings.Split(path, "."))
+	}
+
+	return p
+}
+
+func ignorePath(p plan.Maybe[any], s any, path []string) plan.Maybe[any] {
+	if len(path) == 0 {
+		return PlanValue(s)
+	}
+
+	pm, ok := p.Value.(map[plan.Maybe[string]]plan.Maybe[any])
+	if !ok || p.Unknown {
+		return p
+	}
+
+	sm, _ := s.(map[string]any)
+	out := make(map[plan.Maybe[string]]plan.Maybe[any], len(pm))
+	for k, v := range pm {
+		out[k] = v
+	}
+
+	key := plan.Maybe[string]{Value: path[0]}
+	sv, inState := sm[path[0]]
+	pv, inPlan := pm[key]
+	switch {
+	case !inState && len(path) == 1:
+		delete(out, key)
+	case !inPlan && len(path) == 1:
+		out[key] = PlanValue(sv)
+	case inPlan:
+		out[key] = ignorePath(pv, sv, path[1:])
+	}
+
+	return plan.Maybe[any]{Value: out}
+}
+
+// PlanValue converts a value read from a provider to a known plan value.
+func PlanValue(v any) plan.Maybe[any] {
+	switch v := v.(type) {
+	case state.Sensitive:
+		return plan.Maybe[any]{Value: plan.Sensitive{Value: PlanValue(v.Value).Value}}
+	case map[string]any:
+		out := make(map[plan.Maybe[string]]plan.Maybe[any], len(v))
+		for k, val := range v {
+			out[plan.Maybe[string]{Value: k}] = PlanValue(val)
+		}
+
+		return plan.Maybe[any]{Value: out}
+	default:
+		return plan.Maybe[any]{Value: v}
+	}
+}
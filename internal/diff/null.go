@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"github.com/alchematik/athanor/internal/plan"
+)
+
+func isNull(p Emptyable[plan.Maybe[any]], s Emptyable[any]) bool {
+	if !p.IsEmpty && !p.Value.Unknown && p.Value.Value == nil {
+		return true
+	}
+
+	return !s.IsEmpty && s.Value == nil
+}
+
+// DiffNull diffs values where at least one side is explicitly null. Null is a value of its own, so
+// setting a field to null is an update rather than a delete. Providers return null for fields that were never
+// set, so a null in the state of a field that the blueprint doesn't set is not a change.
+func DiffNull(p Emptyable[plan.Maybe[any]], s Emptyable[any]) (Diff[any], error) {
+	d := Literal[any]{
+		Plan:  Emptyable[any]{Value: p.Value.Value, IsEmpty: p.IsEmpty},
+		State: Emptyable[any]{Value: s.Value, IsEmpty: s.IsEmpty},
+	}
+
+	var action Action
+	switch {
+	case p.IsEmpty && s.IsEmpty:
+		action = ActionNoop
+	case !p.IsEmpty && p.Value.Unknown:
+		action = ActionUnknown
+	case s.IsEmpty:
+		action = ActionCreate
+	case p.IsEmpty && d.State.Value == nil:
+		action = ActionNoop
+	case p.IsEmpty:
+		action = ActionDelete
+	case d.Plan.Value == nil && d.State.Value == nil:
+		action = ActionNoop
+	default:
+		action = ActionUpdate
+	}
+
+	return Diff[any]{Action: action, Diff: d}, nil
+}
@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/alchematik/athanor/internal/plan"
+	"github.com/alchematik/athanor/internal/state"
+)
+
+// Sensitive is the diff of a secret value. Only a hash of each side is kept, which is enough to tell
+// whether the value changed.
+type Sensitive struct {
+	Plan  Emptyable[string]
+	State Emptyable[string]
+}
+
+func (s Sensitive) String() string {
+	return "(sensitive)"
+}
+
+func (s Sensitive) GoString() string {
+	return s.String()
+}
+
+func (s Sensitive) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+func isSensitive(p Emptyable[plan.Maybe[any]], s Emptyable[any]) bool {
+	if !p.IsEmpty {
+		if _, ok := p.Value.Value.(plan.Sensitive); ok {
+			return true
+		}
+	}
+
+	if !s.IsEmpty {
+		if _, ok := s.Value.(state.Sensitive); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func DiffSensitive(p Emptyable[plan.Maybe[any]], s Emptyable[any]) (Diff[any], error) {
+	d := Sensitive{
+		Plan:  Emptyable[string]{IsEmpty: p.IsEmpty},
+		State: Emptyable[string]{IsEmpty: s.IsEmpty},
+	}
+
+	if !s.IsEmpty {
+		h, err := hashValue(plainStateValue(s.Value))
+		if err != nil {
+			return Diff[any]{}, err
+		}
+
+		d.State.Value = h
+	}
+
+	if !p.IsEmpty {
+		v, ok := plan.Plain(p.Value)
+		if !ok {
+			return Diff[any]{Action: ActionUnknown, Diff: d}, nil
+		}
+
+		h, err := hashValue(v)
+		if err != nil {
+			return Diff[any]{}, err
+		}
+
+		d.Plan.Value = h
+	}
+
+	var action Action
+	switch {
+	case p.IsEmpty && s.IsEmpty:
+		action = ActionNoop
+	case s.IsEmpty:
+		action = ActionCreate
+	case p.IsEmpty:
+		action = ActionDelete
+	case d.Plan.Value == d.State.Value:
+		action = ActionNoop
+	default:
+		action = ActionUpdate
+	}
+
+	return Diff[any]{Action: action, Diff: d}, nil
+}
+
+func hashValue(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func plainStateValue(v any) any {
+	switch v := v.(type) {
+	case state.Sensitive:
+		return plainStateValue(v.Value)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = plainStateValue(val)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
@@ -4,16 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os/exec"
 
 	"github.com/alchematik/athanor/internal/dag"
 	"github.com/alchematik/athanor/internal/diff"
 	"github.com/alchematik/athanor/internal/plan"
 	"github.com/alchematik/athanor/internal/scope"
 	"github.com/alchematik/athanor/provider"
-
-	"github.com/hashicorp/go-hclog"
-	plugin "github.com/hashicorp/go-plugin"
 )
 
 type DiffEvaluator struct {
@@ -40,147 +36,75 @@ func (e *DiffEvaluator) Eval(ctx context.Context, d *diff.DiffResult, stmt any)
 
 		current.ToEvaluating()
 
-		if err := e.Iter.Start(stmt.ID); err != nil {
-			return err
-		}
-
-		planCurrent, ok := d.Plan.Resource(stmt.ID)
-		if !ok {
-			return fmt.Errorf("resource not in plan: %s", stmt.ID)
+		if from, ok := e.Scope.MovedFrom(stmt.ID); ok {
+			current.SetMovedFrom(from)
 		}
 
-		planExists, err := stmt.PlanExists.Eval(ctx, d.Plan)
-		if err != nil {
+		if err := e.evalResource(ctx, d, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
-		}
-		planCurrent.SetExists(planExists)
-
-		planType, err := stmt.PlanType.Eval(ctx, d.Plan)
-		if err != nil {
-			current.ToError(err)
-			return nil
-		}
-		planCurrent.SetType(planType)
-
-		planProvider, err := stmt.PlanProvider.Eval(ctx, d.Plan)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		} else {
+			current.ToDone()
 		}
-		planCurrent.SetProvider(planProvider)
 
-		planIdentifier, err := stmt.PlanIdentifier.Eval(ctx, d.Plan)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		// Start only once the values are set, so that components referencing this one can read them.
+		return e.Iter.Start(stmt.ID)
+	case diff.StmtData:
+		current, ok := d.DataLookup(stmt.ID)
+		if !ok {
+			return fmt.Errorf("data not in diff: %s", stmt.ID)
 		}
-		planCurrent.SetIdentifier(planIdentifier)
 
-		planConfig, err := stmt.PlanConfig.Eval(ctx, d.Plan)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		if e.Iter.Visited(stmt.ID) {
+			return e.Iter.Done(stmt.ID)
 		}
-		planCurrent.SetConfig(planConfig)
 
-		stateCurrent, ok := d.State.Resource(stmt.ID)
-		if !ok {
-			return fmt.Errorf("resource not in state: %s", stmt.ID)
-		}
+		current.ToEvaluating()
 
-		t, err := stmt.Type.Eval(ctx, d.State)
-		if err != nil {
+		if err := e.evalData(ctx, d, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
-		}
-		stateCurrent.SetType(t)
 
-		id, err := stmt.Identifier.Eval(ctx, d.State)
-		if err != nil {
-			current.ToError(err)
-			return nil
+			// Expressions that reference the lookup read it from the plan and the state.
+			if planCurrent, ok := d.Plan.DataLookup(stmt.ID); ok {
+				planCurrent.ToError(err)
+			}
+			if stateCurrent, ok := d.State.DataLookup(stmt.ID); ok {
+				stateCurrent.ToError(err)
+			}
+		} else {
+			current.ToDone()
 		}
-		stateCurrent.SetIdentifier(id)
-		current.SetIdentifier(id)
 
-		prov, err := stmt.Provider.Eval(ctx, d.State)
-		if err != nil {
-			current.ToError(err)
-			return nil
-		}
-		stateCurrent.SetProvider(prov)
-		current.SetProvider(prov)
-
-		// TODO: Extract and use provider to determine plugin.
-		client := plugin.NewClient(&plugin.ClientConfig{
-			HandshakeConfig: plugin.HandshakeConfig{
-				ProtocolVersion:  1,
-				MagicCookieKey:   "BASIC_PLUGIN",
-				MagicCookieValue: "hello",
-			},
-			Plugins: map[string]plugin.Plugin{
-				"provider": &provider.Plugin{},
-			},
-			Cmd:    exec.Command(".provider/google-cloud-v0.0.1"),
-			Logger: hclog.NewNullLogger(),
-		})
-		defer client.Kill()
-
-		c, err := client.Client()
-		if err != nil {
-			current.ToError(err)
-			return nil
+		return e.Iter.Start(stmt.ID)
+	case diff.StmtOutput:
+		if e.Iter.Visited(stmt.ID) {
+			return e.Iter.Done(stmt.ID)
 		}
 
-		pr, err := c.Dispense("provider")
-		if err != nil {
-			current.ToError(err)
-			return nil
+		planCurrent, ok := d.Plan.Output(stmt.ID)
+		if !ok {
+			return fmt.Errorf("output not in plan: %s", stmt.ID)
 		}
 
-		providerClient, ok := pr.(*provider.Client)
+		stateCurrent, ok := d.State.Output(stmt.ID)
 		if !ok {
-			current.ToError(fmt.Errorf("invalid provider client: %T", pr))
-			return nil
+			return fmt.Errorf("output not in state: %s", stmt.ID)
 		}
 
-		res, err := providerClient.Get(ctx, provider.GetResourceRequest{
-			Type:       t,
-			Identifier: id,
-		})
-		if err != nil {
-			current.ToError(err)
-			return nil
+		if value, err := stmt.PlanValue.Eval(ctx, d.Plan); err != nil {
+			planCurrent.ToError(err)
+		} else {
+			planCurrent.SetValue(value)
+			planCurrent.ToDone()
 		}
 
-		// TODO: Handle case where doesn't exist.
-		stateCurrent.SetExists(true)
-		stateCurrent.SetConfig(res.Resource.Config)
-		stateCurrent.SetAttributes(res.Resource.Attrs)
-
-		// TODO: diff and set on current.
-		existsDiff, err := diff.DiffLiteral[bool](
-			diff.Emptyable[plan.Maybe[bool]]{Value: planExists},
-			diff.Emptyable[bool]{Value: true},
-		)
-		current.SetExists(existsDiff)
-
-		configDiff, err := diff.DiffAny(
-			diff.Emptyable[plan.Maybe[any]]{Value: planConfig},
-			diff.Emptyable[any]{Value: res.Resource.Config},
-		)
-		current.SetConfig(configDiff)
-
-		action := existsDiff.Action
-		if action == diff.ActionNoop {
-			action = configDiff.Action
+		if value, err := stmt.StateValue.Eval(ctx, d.State); err != nil {
+			stateCurrent.ToError(err)
+		} else {
+			stateCurrent.SetValue(value)
+			stateCurrent.ToDone()
 		}
 
-		current.SetAction(action)
-
-		current.ToDone()
-		return nil
+		return e.Iter.Start(stmt.ID)
 	case diff.StmtBuild:
 		current, ok := d.Build(stmt.ID)
 		if !ok {
@@ -212,8 +136,181 @@ func (e *DiffEvaluator) Eval(ctx context.Context, d *diff.DiffResult, stmt any)
 		}
 
 		current.ToEvaluating()
+
+		if from, ok := e.Scope.MovedFrom(stmt.ID); ok {
+			current.SetMovedFrom(from)
+		}
+
 		return e.Iter.Start(stmt.ID)
 	default:
 		return fmt.Errorf("unsupported component type: %T", stmt)
 	}
 }
+
+func (e *DiffEvaluator) evalResource(ctx context.Context, d *diff.DiffResult, stmt diff.StmtResource, current *diff.ResourceDiff) error {
+	planCurrent, ok := d.Plan.Resource(stmt.ID)
+	if !ok {
+		return fmt.Errorf("resource not in plan: %s", stmt.ID)
+	}
+
+	planExists, err := stmt.PlanExists.Eval(ctx, d.Plan)
+	if err != nil {
+		return err
+	}
+	planCurrent.SetExists(planExists)
+
+	planType, err := stmt.PlanType.Eval(ctx, d.Plan)
+	if err != nil {
+		return err
+	}
+	planCurrent.SetType(planType)
+
+	planProvider, err := stmt.PlanProvider.Eval(ctx, d.Plan)
+	if err != nil {
+		return err
+	}
+	planCurrent.SetProvider(planProvider)
+
+	planIdentifier, err := stmt.PlanIdentifier.Eval(ctx, d.Plan)
+	if err != nil {
+		return err
+	}
+	planCurrent.SetIdentifier(planIdentifier)
+
+	planConfig, err := stmt.PlanConfig.Eval(ctx, d.Plan)
+	if err != nil {
+		return err
+	}
+	planCurrent.SetConfig(planConfig)
+
+	stateCurrent, ok := d.State.Resource(stmt.ID)
+	if !ok {
+		return fmt.Errorf("resource not in state: %s", stmt.ID)
+	}
+
+	t, err := stmt.Type.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+	stateCurrent.SetType(t)
+
+	id, err := stmt.Identifier.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+	stateCurrent.SetIdentifier(id)
+	current.SetIdentifier(id)
+
+	prov, err := stmt.Provider.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+	stateCurrent.SetProvider(prov)
+	current.SetProvider(prov)
+
+	res, err := getResource(ctx, provider.GetResourceRequest{
+		Type:       t,
+		Identifier: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	stateCurrent.SetExists(!res.NotFound)
+
+	stateConfig := diff.Emptyable[any]{Value: applySchema(res.Resource.Config, res.Schema), IsEmpty: res.NotFound}
+	if !res.NotFound {
+		stateCurrent.SetConfig(stateConfig.Value)
+		stateCurrent.SetAttributes(applySchema(res.Resource.Attrs, res.Schema))
+	}
+
+	existsDiff, err := diff.DiffLiteral[bool](
+		diff.Emptyable[plan.Maybe[bool]]{Value: planExists},
+		diff.Emptyable[bool]{Value: !res.NotFound},
+	)
+	if err != nil {
+		return err
+	}
+	current.SetExists(existsDiff)
+
+	// What should exist is only compared with what does.
+	planConfigValue := diff.Emptyable[plan.Maybe[any]]{Value: planConfig, IsEmpty: !planExists.Unknown && !planExists.Value}
+	if !planConfigValue.IsEmpty && !stateConfig.IsEmpty {
+		planConfigValue.Value = diff.IgnoreChanges(planConfig, stateConfig.Value, stmt.Lifecycle.IgnoreChanges)
+	}
+
+	configDiff, err := diff.DiffAny(planConfigValue, stateConfig)
+	if err != nil {
+		return err
+	}
+	current.SetConfig(configDiff)
+
+	action, err := stmt.Lifecycle.Action(planExists, !res.NotFound, configDiff, diff.RequiresReplace(configDiff, replaceKeys(res.Schema)))
+	current.SetAction(action)
+
+	return err
+}
+
+// evalData reads a data lookup and records what was read as both its plan and its state.
+func (e *DiffEvaluator) evalData(ctx context.Context, d *diff.DiffResult, stmt diff.StmtData, current *diff.DataDiff) error {
+	planCurrent, ok := d.Plan.DataLookup(stmt.ID)
+	if !ok {
+		return fmt.Errorf("data not in plan: %s", stmt.ID)
+	}
+
+	stateCurrent, ok := d.State.DataLookup(stmt.ID)
+	if !ok {
+		return fmt.Errorf("data not in state: %s", stmt.ID)
+	}
+
+	t, err := stmt.Type.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+
+	prov, err := stmt.Provider.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+	current.SetProvider(prov)
+
+	id, err := stmt.Identifier.Eval(ctx, d.State)
+	if err != nil {
+		return err
+	}
+	current.SetIdentifier(id)
+
+	res, err := getResource(ctx, provider.GetResourceRequest{
+		Type:       t,
+		Identifier: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.NotFound {
+		return fmt.Errorf("%s: %s does not exist", stmt.ID, t)
+	}
+
+	config := applySchema(res.Resource.Config, res.Schema)
+	attrs := applySchema(res.Resource.Attrs, res.Schema)
+
+	stateCurrent.SetType(t)
+	stateCurrent.SetProvider(prov)
+	stateCurrent.SetIdentifier(id)
+	stateCurrent.SetConfig(config)
+	stateCurrent.SetAttributes(attrs)
+	stateCurrent.ToDone()
+
+	planCurrent.SetType(plan.Maybe[string]{Value: t})
+	planCurrent.SetProvider(plan.Maybe[plan.Provider]{Value: plan.Provider{
+		Name:    plan.Maybe[string]{Value: prov.Name},
+		Version: plan.Maybe[string]{Value: prov.Version},
+	}})
+	planCurrent.SetIdentifier(diff.PlanValue(id))
+	planCurrent.SetConfig(diff.PlanValue(config))
+	planCurrent.SetAttributes(diff.PlanValue(attrs))
+	planCurrent.ToDone()
+
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"log/slog"
 
 	"github.com/alchematik/athanor/internal/dag"
+	"github.com/alchematik/athanor/internal/diff"
 	"github.com/alchematik/athanor/internal/plan"
+	"github.com/alchematik/athanor/provider"
 )
 
 func NewPlanEvaluator(iter *dag.Iterator, logger *slog.Logger) *PlanEvaluator {
@@ -36,57 +38,54 @@ func (e *PlanEvaluator) Eval(ctx context.Context, p *plan.Plan, stmt any) error
 
 		current.ToEvaluating()
 
-		if err := e.iter.Start(stmt.ID); err != nil {
-			return err
-		}
-
-		exists, err := stmt.Exists.Eval(ctx, p)
-		if err != nil {
+		if err := e.evalResource(ctx, p, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
+		} else {
+			current.ToDone()
 		}
-		current.SetExists(exists)
 
-		t, err := stmt.Type.Eval(ctx, p)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		// Start only once the values are set, so that components referencing this one can read them.
+		return e.iter.Start(stmt.ID)
+	case plan.StmtData:
+		current, ok := p.DataLookup(stmt.ID)
+		if !ok {
+			return fmt.Errorf("data not in plan: %s", stmt.ID)
 		}
-		current.SetType(t)
 
-		provider, err := stmt.Provider.Eval(ctx, p)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		if e.iter.Visited(stmt.ID) {
+			return e.iter.Done(stmt.ID)
 		}
-		current.SetProvider(provider)
 
-		id, err := stmt.Identifier.Eval(ctx, p)
-		if err != nil {
+		current.ToEvaluating()
+
+		if err := e.evalData(ctx, p, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
+		} else {
+			current.ToDone()
 		}
-		current.SetIdentifier(id)
 
-		config, err := stmt.Config.Eval(ctx, p)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		return e.iter.Start(stmt.ID)
+	case plan.StmtOutput:
+		current, ok := p.Output(stmt.ID)
+		if !ok {
+			return fmt.Errorf("output not in plan: %s", stmt.ID)
 		}
-		current.SetConfig(config)
 
-		parent, ok := p.Build(stmt.BuildID)
-		if ok {
-			// Parent exists value is known, and it's set to false. Child resource exists should be false also.
-			parentExists := parent.GetExists()
-			if !exists.Unknown && !parentExists.Unknown && !parentExists.Value {
-				exists.Value = false
-			}
+		if e.iter.Visited(stmt.ID) {
+			return e.iter.Done(stmt.ID)
 		}
 
-		current.ToDone()
+		current.ToEvaluating()
+
+		value, err := stmt.Value.Eval(ctx, p)
+		if err != nil {
+			current.ToError(err)
+		} else {
+			current.SetValue(value)
+			current.ToDone()
+		}
 
-		return nil
+		return e.iter.Start(stmt.ID)
 	case plan.StmtBuild:
 		current, ok := p.Build(stmt.ID)
 		if !ok {
@@ -123,3 +122,93 @@ func (e *PlanEvaluator) Eval(ctx context.Context, p *plan.Plan, stmt any) error
 		return fmt.Errorf("unsupported component type: %T", stmt)
 	}
 }
+
+func (e *PlanEvaluator) evalResource(ctx context.Context, p *plan.Plan, stmt plan.StmtResource, current *plan.ResourcePlan) error {
+	exists, err := stmt.Exists.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetExists(exists)
+
+	t, err := stmt.Type.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetType(t)
+
+	provider, err := stmt.Provider.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetProvider(provider)
+
+	id, err := stmt.Identifier.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetIdentifier(id)
+
+	config, err := stmt.Config.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetConfig(config)
+
+	parent, ok := p.Build(stmt.BuildID)
+	if ok {
+		// Parent exists value is known, and it's set to false. Child resource exists should be false also.
+		parentExists := parent.GetExists()
+		if !exists.Unknown && !parentExists.Unknown && !parentExists.Value {
+			exists.Value = false
+		}
+	}
+
+	return nil
+}
+
+// evalData reads a data lookup through its provider, so that its values are known while planning. They stay
+// unknown if the lookup depends on values that are only known after reconciling.
+func (e *PlanEvaluator) evalData(ctx context.Context, p *plan.Plan, stmt plan.StmtData, current *plan.DataPlan) error {
+	t, err := stmt.Type.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetType(t)
+
+	prov, err := stmt.Provider.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetProvider(prov)
+
+	id, err := stmt.Identifier.Eval(ctx, p)
+	if err != nil {
+		return err
+	}
+	current.SetIdentifier(id)
+
+	resourceType, typeKnown := t.Unwrap()
+	plainID, idKnown := plan.Plain(id)
+	if !typeKnown || !idKnown {
+		current.SetConfig(plan.Maybe[any]{Unknown: true})
+		current.SetAttributes(plan.Maybe[any]{Unknown: true})
+		return nil
+	}
+
+	res, err := getResource(ctx, provider.GetResourceRequest{
+		Type:       resourceType,
+		Identifier: plainID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.NotFound {
+		return fmt.Errorf("%s: %s does not exist", stmt.ID, resourceType)
+	}
+
+	current.SetConfig(diff.PlanValue(applySchema(res.Resource.Config, res.Schema)))
+	current.SetAttributes(diff.PlanValue(applySchema(res.Resource.Attrs, res.Schema)))
+
+	return nil
+}
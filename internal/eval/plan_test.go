@@ -0,0 +1,358 @@
+package eval_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/ast"
+	bp "github.com/alchematik/athanor/blueprint"
+	"github.com/alchematik/athanor/internal/eval"
+	"github.com/alchematik/athanor/internal/interpreter"
+	"github.com/alchematik/athanor/internal/parallel"
+	"github.com/alchematik/athanor/internal/plan"
+	"github.com/alchematik/athanor/internal/scope"
+)
+
+func TestPlanEvaluator_Outputs(t *testing.T) {
+	network := bp.Build(
+		bp.Resource("vpc", "network", bp.Provider("google-cloud", "v0.0.1")).
+			Identifier(bp.Map{"name": bp.String("my-vpc")}),
+		bp.Output("region", bp.String("us-east1")),
+		bp.Output("vpc", bp.GetResource("vpc")),
+	)
+	root := bp.Build(
+		// Declared before the build it references.
+		bp.Output("region", bp.GetOutput("network", "region")),
+		bp.InlineBuild("network", network),
+		bp.InlineBuild("app", bp.Build()).RuntimeInput(bp.Map{"vpc": bp.GetOutput("network", "vpc")}),
+	).AST()
+
+	p, sc, evaluated := evalPlan(t, root)
+
+	require.ElementsMatch(t, []string{".Build#region"}, sc.Outputs(".Build"))
+	require.ElementsMatch(t, []string{".Build.network#region", ".Build.network#vpc"}, sc.Outputs(".Build.network"))
+
+	region, ok := p.Output(".Build#region")
+	require.True(t, ok)
+	require.Equal(t, "done", region.GetEvalState().State)
+	require.Equal(t, plan.Maybe[any]{Value: "us-east1"}, region.Value())
+
+	vpc, ok := p.Output(".Build.network#vpc")
+	require.True(t, ok)
+	require.Equal(t, "done", vpc.GetEvalState().State)
+	require.Equal(t, plan.Maybe[any]{Value: map[plan.Maybe[string]]plan.Maybe[any]{
+		{Value: "identifier"}: {Value: map[plan.Maybe[string]]plan.Maybe[any]{{Value: "name"}: {Value: "my-vpc"}}},
+		{Value: "config"}:     {Value: map[plan.Maybe[string]]plan.Maybe[any]{}},
+		{Value: "attributes"}: {Unknown: true},
+	}}, vpc.Value())
+
+	// The app build waits for the output it takes as runtime input.
+	require.Less(t, indexOf(evaluated, ".Build.network#vpc"), indexOf(evaluated, ".Build.app"))
+
+	b, ok := p.Build(".Build")
+	require.True(t, ok)
+	require.Equal(t, "done", b.GetEvalState().State)
+}
+
+// evalPlan plans root as the root build and returns the IDs of the components in the order they were evaluated.
+func evalPlan(t *testing.T, root ast.Blueprint) (*plan.Plan, *scope.Scope, []string) {
+	t.Helper()
+
+	p, sc, err := convertPlan(root)
+	require.NoError(t, err)
+
+	iter, err := sc.NewIterator()
+	require.NoError(t, err)
+
+	e := eval.NewPlanEvaluator(iter, nil)
+	var evaluated []string
+	for ids := e.Next(); len(ids) > 0; ids = e.Next() {
+		for _, id := range ids {
+			comp, ok := sc.Component(id)
+			require.True(t, ok, id)
+			require.NoError(t, e.Eval(context.Background(), p, comp))
+			evaluated = append(evaluated, id)
+		}
+	}
+
+	return p, sc, evaluated
+}
+
+// convertPlan converts root as the root build.
+func convertPlan(root ast.Blueprint) (*plan.Plan, *scope.Scope, error) {
+	sc := scope.NewScope()
+	p := &plan.Plan{
+		Resources: map[string]*plan.ResourcePlan{},
+		Builds:    map[string]*plan.BuildPlan{},
+		Outputs:   map[string]*plan.OutputPlan{},
+		Data:      map[string]*plan.DataPlan{},
+	}
+	c := plan.Converter{BlueprintInterpreter: &interpreter.Interpreter{}}
+	_, err := c.ConvertBuildStmt(p, sc, "", ast.DeclareBuild{
+		Name:            "Build",
+		Exists:          bp.Bool(true).Expr(),
+		Runtimeinput:    bp.Map{}.Expr(),
+		BlueprintSource: ast.BlueprintSource{Inline: &root},
+	})
+
+	return p, sc, err
+}
+
+func indexOf(ids []string, id string) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestPlanEvaluator_DataUnknownIdentifier(t *testing.T) {
+	root := bp.Build(
+		bp.Resource("vpc", "network", bp.Provider("google-cloud", "v0.0.1")),
+		// The identifier depends on attributes that are only known after reconciling, so the lookup can't
+		// be read yet.
+		bp.Data("subnet", "subnet", bp.Provider("google-cloud", "v0.0.1")).
+			Identifier(bp.Map{"vpc": bp.GetResource("vpc")}),
+		bp.Output("subnet", bp.GetData("subnet")),
+	).AST()
+
+	p, _, _ := evalPlan(t, root)
+
+	subnet, ok := p.DataLookup(".Build.subnet")
+	require.True(t, ok)
+	require.Equal(t, "done", subnet.GetEvalState().State)
+	require.Equal(t, plan.Maybe[any]{Unknown: true}, subnet.Attributes())
+
+	out, ok := p.Output(".Build#subnet")
+	require.True(t, ok)
+	require.Equal(t, "done", out.GetEvalState().State)
+}
+
+func TestPlanEvaluator_ForEach(t *testing.T) {
+	blueprint := func(regions ...bp.Value) ast.Blueprint {
+		return bp.Build(
+			bp.Resource("buckets", "bucket", bp.Provider("google-cloud", "v0.0.1")).
+				ForEach(bp.List(regions)).
+				Identifier(bp.Map{"name": bp.EachKey()}),
+			bp.InlineBuild("replicas", bp.Build()).
+				Count(2).
+				RuntimeInput(bp.Map{"index": bp.EachValue()}),
+			bp.Output("bucket", bp.GetResource("buckets[us-east1]")),
+		).AST()
+	}
+
+	p, sc, _ := evalPlan(t, blueprint(bp.String("us-east1")))
+	require.ElementsMatch(t, []string{".Build.buckets[us-east1]"}, sc.Resources(".Build"))
+	require.ElementsMatch(t, []string{".Build.replicas[0]", ".Build.replicas[1]"}, sc.Builds(".Build"))
+
+	before, ok := p.Resource(".Build.buckets[us-east1]")
+	require.True(t, ok)
+	require.Equal(t, "buckets[us-east1]", before.GetName())
+
+	out, ok := p.Output(".Build#bucket")
+	require.True(t, ok)
+	require.Equal(t, "done", out.GetEvalState().State)
+
+	// Adding a key only adds its instance and leaves the existing one as it was.
+	p, sc, _ = evalPlan(t, blueprint(bp.String("us-east1"), bp.String("eu-west1")))
+	require.ElementsMatch(t, []string{".Build.buckets[us-east1]", ".Build.buckets[eu-west1]"}, sc.Resources(".Build"))
+
+	after, ok := p.Resource(".Build.buckets[us-east1]")
+	require.True(t, ok)
+	require.Equal(t, before, after)
+
+	added, ok := p.Resource(".Build.buckets[eu-west1]")
+	require.True(t, ok)
+	require.Equal(t, plan.Maybe[any]{Value: map[plan.Maybe[string]]plan.Maybe[any]{
+		{Value: "name"}: {Value: "eu-west1"},
+	}}, added.Identifier())
+}
+
+func TestPlanEvaluator_DependsOn(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	root := bp.Build(
+		bp.Resource("app", "instance", provider).DependsOn("db", "network.vpc", "buckets"),
+		bp.Resource("db", "database", provider),
+		bp.Resource("buckets", "bucket", provider).ForEach(bp.List{bp.String("a"), bp.String("b")}),
+		bp.InlineBuild("network", bp.Build(
+			bp.Resource("vpc", "network", provider),
+		)),
+	).AST()
+
+	_, _, evaluated := evalPlan(t, root)
+
+	for _, id := range []string{".Build.db", ".Build.network.vpc", ".Build.buckets[a]", ".Build.buckets[b]"} {
+		require.Less(t, indexOf(evaluated, id), indexOf(evaluated, ".Build.app"), id)
+	}
+}
+
+func TestPlanEvaluator_DependsOnBuild(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	root := bp.Build(
+		bp.Resource("app", "instance", provider).DependsOn("network"),
+		bp.InlineBuild("network", bp.Build(
+			bp.Resource("vpc", "network", provider),
+			bp.InlineBuild("inner", bp.Build(
+				bp.Resource("subnet", "subnetwork", provider),
+			)),
+			bp.Output("name", bp.String("vpc")),
+		)),
+	).AST()
+
+	_, _, evaluated := evalPlan(t, root)
+
+	for _, id := range []string{".Build.network", ".Build.network.vpc", ".Build.network.inner", ".Build.network.inner.subnet", ".Build.network#name"} {
+		require.Less(t, indexOf(evaluated, id), indexOf(evaluated, ".Build.app"), id)
+	}
+}
+
+func TestPlanEvaluator_DependsOnMissing(t *testing.T) {
+	root := bp.Build(
+		bp.Resource("app", "instance", bp.Provider("google-cloud", "v0.0.1")).DependsOn("db"),
+	).AST()
+
+	_, sc, err := convertPlan(root)
+	require.NoError(t, err)
+
+	_, err = sc.NewIterator()
+	require.EqualError(t, err, `.Build.app: depends on "db", which is not a resource or build`)
+}
+
+func TestPlanEvaluator_ReferenceMissing(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	root := bp.Build(
+		bp.Resource("app", "instance", provider).Config(bp.Map{
+			"db":     bp.GetResource("db"),
+			"region": bp.GetData("region"),
+		}),
+		bp.Output("url", bp.GetOutput("sub", "url")),
+		bp.InlineBuild("sub", bp.Build()),
+	).AST()
+
+	_, sc, err := convertPlan(root)
+	require.NoError(t, err)
+
+	_, err = sc.NewIterator()
+	require.EqualError(t, err, `.Build#url: references output "sub.url", which is not declared
+.Build.app: references resource "db", which is not declared
+.Build.app: references data "region", which is not declared`)
+}
+
+func TestPlanEvaluator_DependsOnCycle(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	root := bp.Build(
+		bp.Resource("a", "instance", provider).DependsOn("b"),
+		bp.Resource("b", "instance", provider).DependsOn("a"),
+	).AST()
+
+	_, sc, err := convertPlan(root)
+	require.NoError(t, err)
+
+	_, err = sc.NewIterator()
+	require.EqualError(t, err, "dependency cycle: .Build.a -> .Build.b -> .Build.a")
+}
+
+func TestPlanConverter_Inputs(t *testing.T) {
+	sub := bp.Build().Accepts(bp.Input("region", "string").Required())
+	root := bp.Build(
+		bp.InlineBuild("sub", sub).RuntimeInput(bp.Map{"regoin": bp.String("us-east1")}),
+	).AST()
+
+	_, _, err := convertPlan(root)
+	require.EqualError(t, err, ".Build.sub.runtime_input.regoin: blueprint (inline) has no input \"regoin\"\n"+
+		".Build.sub: blueprint (inline) requires input \"region\"")
+}
+
+func TestPlanConverter_Moved(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	root := bp.Build(
+		bp.Moved("bucket", "storage.bucket"),
+		bp.InlineBuild("storage", bp.Build(bp.Resource("bucket", "bucket", provider))),
+	).AST()
+
+	_, sc, _ := evalPlan(t, root)
+
+	from, ok := sc.MovedFrom(".Build.storage.bucket")
+	require.True(t, ok)
+	require.Equal(t, ".Build.bucket", from)
+
+	root = bp.Build(
+		bp.Moved("bucket", "missing"),
+		bp.Resource("bucket", "bucket", provider),
+	).AST()
+
+	_, sc, err := convertPlan(root)
+	require.NoError(t, err)
+
+	_, err = sc.NewIterator()
+	require.EqualError(t, err, ".Build.bucket: moved to .Build.missing but still declared\n"+
+		".Build.bucket: moved to .Build.missing, which is not a resource or build")
+
+	root = bp.Build(
+		bp.Moved("bucket", "storage"),
+		bp.Moved("old-bucket", "storage"),
+		bp.Resource("storage", "bucket", provider),
+	).AST()
+
+	_, sc, err = convertPlan(root)
+	require.NoError(t, err)
+
+	_, err = sc.NewIterator()
+	require.EqualError(t, err, ".Build.old-bucket: moved to .Build.storage, which .Build.bucket is already moved to")
+}
+
+func TestPlanConverter_ParallelBuilds(t *testing.T) {
+	provider := bp.Provider("google-cloud", "v0.0.1")
+	sub := bp.Build(
+		bp.Resource("bucket", "bucket", provider),
+		bp.InlineBuild("nested", bp.Build(bp.Resource("bucket", "bucket", provider))),
+	)
+	root := bp.Build(
+		bp.Resource("first", "bucket", provider),
+		bp.InlineBuild("sub", sub).Count(20),
+		bp.Resource("last", "bucket", provider),
+	).AST()
+
+	sc := scope.NewScope()
+	p := &plan.Plan{
+		Resources: map[string]*plan.ResourcePlan{},
+		Builds:    map[string]*plan.BuildPlan{},
+		Outputs:   map[string]*plan.OutputPlan{},
+		Data:      map[string]*plan.DataPlan{},
+	}
+	c := plan.Converter{BlueprintInterpreter: &interpreter.Interpreter{}, Interpreting: parallel.Limiter{N: 4}}
+	b, err := c.ConvertBuildStmt(p, sc, "", ast.DeclareBuild{
+		Name:            "Build",
+		Exists:          bp.Bool(true).Expr(),
+		Runtimeinput:    bp.Map{}.Expr(),
+		BlueprintSource: ast.BlueprintSource{Inline: &root},
+	})
+	require.NoError(t, err)
+
+	// Statements keep the order they are declared in.
+	ids := []string{".Build.first"}
+	for i := 0; i < 20; i++ {
+		ids = append(ids, fmt.Sprintf(".Build.sub[%d]", i))
+	}
+	ids = append(ids, ".Build.last")
+
+	var converted []string
+	for _, stmt := range b.Stmts {
+		switch stmt := stmt.(type) {
+		case plan.StmtResource:
+			converted = append(converted, stmt.ID)
+		case plan.StmtBuild:
+			converted = append(converted, stmt.ID)
+		}
+	}
+	require.Equal(t, ids, converted)
+
+	require.Len(t, p.Builds, 41)
+	require.Len(t, p.Resources, 42)
+	require.Len(t, sc.Builds(".Build"), 20)
+}
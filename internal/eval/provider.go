@@ -0,0 +1,47 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/alchematik/athanor/provider"
+
+	"github.com/hashicorp/go-hclog"
+	plugin "github.com/hashicorp/go-plugin"
+)
+
+// getResource reads an object through its provider plugin.
+func getResource(ctx context.Context, req provider.GetResourceRequest) (provider.GetResourceResponse, error) {
+	// TODO: Extract and use provider to determine plugin.
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: plugin.HandshakeConfig{
+			ProtocolVersion:  1,
+			MagicCookieKey:   "BASIC_PLUGIN",
+			MagicCookieValue: "hello",
+		},
+		Plugins: map[string]plugin.Plugin{
+			"provider": &provider.Plugin{},
+		},
+		Cmd:    exec.Command(".provider/google-cloud-v0.0.1"),
+		Logger: hclog.NewNullLogger(),
+	})
+	defer client.Kill()
+
+	c, err := client.Client()
+	if err != nil {
+		return provider.GetResourceResponse{}, err
+	}
+
+	pr, err := c.Dispense("provider")
+	if err != nil {
+		return provider.GetResourceResponse{}, err
+	}
+
+	providerClient, ok := pr.(*provider.Client)
+	if !ok {
+		return provider.GetResourceResponse{}, fmt.Errorf("invalid provider client: %T", pr)
+	}
+
+	return providerClient.Get(ctx, req)
+}
@@ -0,0 +1,37 @@
+package eval
+
+import (
+	"github.com/alchematik/athanor/internal/state"
+	"github.com/alchematik/athanor/provider"
+)
+
+// applySchema wraps the values the provider flagged as sensitive so that they are never displayed.
+func applySchema(v any, schema provider.Schema) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	out := make(map[string]any, len(m))
+	for k, val := range m {
+		if attr, ok := schema.Attributes[k]; ok && attr.Sensitive {
+			val = state.Sensitive{Value: val}
+		}
+
+		out[k] = val
+	}
+
+	return out
+}
+
+// replaceKeys are the attributes the provider can't change in place.
+func replaceKeys(schema provider.Schema) map[string]bool {
+	keys := map[string]bool{}
+	for k, attr := range schema.Attributes {
+		if attr.RequiresReplace {
+			keys[k] = true
+		}
+	}
+
+	return keys
+}
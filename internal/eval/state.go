@@ -5,14 +5,10 @@ import (
 	"encoding/gob"
 	"fmt"
 	"log/slog"
-	"os/exec"
 
 	"github.com/alchematik/athanor/internal/dag"
 	"github.com/alchematik/athanor/internal/state"
 	"github.com/alchematik/athanor/provider"
-
-	"github.com/hashicorp/go-hclog"
-	plugin "github.com/hashicorp/go-plugin"
 )
 
 func init() {
@@ -51,81 +47,54 @@ func (e *StateEvaluator) Eval(ctx context.Context, s *state.State, stmt any) err
 
 		current.ToEvaluating()
 
-		if err := e.Iter.Start(stmt.ID); err != nil {
-			return err
-		}
-
-		t, err := stmt.Type.Eval(ctx, s)
-		if err != nil {
+		if err := e.evalResource(ctx, s, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
+		} else {
+			current.ToDone()
 		}
-		current.SetType(t)
 
-		// TODO: Use provider to initialize plugin client.
-		prov, err := stmt.Provider.Eval(ctx, s)
-		if err != nil {
-			current.ToError(err)
-			return nil
+		// Start only once the values are set, so that components referencing this one can read them.
+		return e.Iter.Start(stmt.ID)
+	case state.StmtData:
+		current, ok := s.DataLookup(stmt.ID)
+		if !ok {
+			return fmt.Errorf("data not in state: %s", stmt.ID)
 		}
-		current.SetProvider(prov)
 
-		id, err := stmt.Identifier.Eval(ctx, s)
-		if err != nil {
-			current.ToError(err)
-			return nil
-		}
-		current.SetIdentifier(id)
-
-		// TODO: Extract and use provider to determine plugin.
-		client := plugin.NewClient(&plugin.ClientConfig{
-			HandshakeConfig: plugin.HandshakeConfig{
-				ProtocolVersion:  1,
-				MagicCookieKey:   "BASIC_PLUGIN",
-				MagicCookieValue: "hello",
-			},
-			Plugins: map[string]plugin.Plugin{
-				"provider": &provider.Plugin{},
-			},
-			Cmd:    exec.Command(".provider/google-cloud-v0.0.1"),
-			Logger: hclog.NewNullLogger(),
-		})
-		defer client.Kill()
-
-		c, err := client.Client()
-		if err != nil {
-			current.ToError(err)
-			return nil
+		if e.Iter.Visited(stmt.ID) {
+			return e.Iter.Done(stmt.ID)
 		}
 
-		pr, err := c.Dispense("provider")
-		if err != nil {
+		current.ToEvaluating()
+
+		if err := e.evalData(ctx, s, stmt, current); err != nil {
 			current.ToError(err)
-			return nil
+		} else {
+			current.ToDone()
 		}
 
-		providerClient, ok := pr.(*provider.Client)
+		return e.Iter.Start(stmt.ID)
+	case state.StmtOutput:
+		current, ok := s.Output(stmt.ID)
 		if !ok {
-			current.ToError(fmt.Errorf("invalid provider client: %T", pr))
-			return nil
+			return fmt.Errorf("output not in state: %s", stmt.ID)
 		}
 
-		res, err := providerClient.Get(ctx, provider.GetResourceRequest{
-			Type:       t,
-			Identifier: id,
-		})
+		if e.Iter.Visited(stmt.ID) {
+			return e.Iter.Done(stmt.ID)
+		}
+
+		current.ToEvaluating()
+
+		value, err := stmt.Value.Eval(ctx, s)
 		if err != nil {
 			current.ToError(err)
-			return nil
+		} else {
+			current.SetValue(value)
+			current.ToDone()
 		}
 
-		// TODO: Handle case where doesn't exist
-		current.SetExists(true)
-		current.SetConfig(res.Resource.Config)
-		current.SetAttributes(res.Resource.Attrs)
-
-		current.ToDone()
-		return nil
+		return e.Iter.Start(stmt.ID)
 	case state.StmtBuild:
 		current, ok := s.Build(stmt.ID)
 		if !ok {
@@ -148,3 +117,76 @@ func (e *StateEvaluator) Eval(ctx context.Context, s *state.State, stmt any) err
 		return fmt.Errorf("unsupported component type: %T", stmt)
 	}
 }
+
+func (e *StateEvaluator) evalResource(ctx context.Context, s *state.State, stmt state.StmtResource, current *state.ResourceState) error {
+	t, err := stmt.Type.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetType(t)
+
+	// TODO: Use provider to initialize plugin client.
+	prov, err := stmt.Provider.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetProvider(prov)
+
+	id, err := stmt.Identifier.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetIdentifier(id)
+
+	res, err := getResource(ctx, provider.GetResourceRequest{
+		Type:       t,
+		Identifier: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	// TODO: Handle case where doesn't exist
+	current.SetExists(true)
+	current.SetConfig(applySchema(res.Resource.Config, res.Schema))
+	current.SetAttributes(applySchema(res.Resource.Attrs, res.Schema))
+
+	return nil
+}
+
+func (e *StateEvaluator) evalData(ctx context.Context, s *state.State, stmt state.StmtData, current *state.DataState) error {
+	t, err := stmt.Type.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetType(t)
+
+	prov, err := stmt.Provider.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetProvider(prov)
+
+	id, err := stmt.Identifier.Eval(ctx, s)
+	if err != nil {
+		return err
+	}
+	current.SetIdentifier(id)
+
+	res, err := getResource(ctx, provider.GetResourceRequest{
+		Type:       t,
+		Identifier: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res.NotFound {
+		return fmt.Errorf("%s: %s does not exist", stmt.ID, t)
+	}
+
+	current.SetConfig(applySchema(res.Resource.Config, res.Schema))
+	current.SetAttributes(applySchema(res.Resource.Attrs, res.Schema))
+
+	return nil
+}
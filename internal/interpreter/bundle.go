@@ -0,0 +1,189 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BundleGuestPath is where blueprint programs find the files of their bundle.
+const BundleGuestPath = "/bundle"
+
+// bundleCache copies each bundle once per content, and gives every program with that bundle the same copy. The
+// copies are kept until the interpreter is closed.
+type bundleCache struct {
+	mu     sync.Mutex
+	dir    string
+	copies map[string]*bundleCopy
+}
+
+// bundleCopy is made once, by whoever needs it first. Others wait for it.
+type bundleCopy struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+// get returns the copy of the bundle at src.
+func (c *bundleCache) get(src string) (string, error) {
+	sum, err := hashBundle(src)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.dir == "" {
+		c.dir, err = os.MkdirTemp("", "athanor-bundles-")
+	}
+	if c.copies == nil {
+		c.copies = map[string]*bundleCopy{}
+	}
+	copied, ok := c.copies[sum]
+	if !ok {
+		copied = &bundleCopy{dir: filepath.Join(c.dir, sum)}
+		c.copies[sum] = copied
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	copied.once.Do(func() {
+		copied.err = copyBundle(src, copied.dir)
+	})
+
+	return copied.dir, copied.err
+}
+
+// close removes the copies. Their directories are made writable again first, since they can't be emptied
+// otherwise.
+func (c *bundleCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dir == "" {
+		return nil
+	}
+
+	filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(path, 0o755)
+		}
+
+		return nil
+	})
+
+	err := os.RemoveAll(c.dir)
+	c.dir = ""
+	c.copies = nil
+	return err
+}
+
+// hashBundle digests the names and contents of the regular files and directories in src, the ones that
+// copyBundle copies.
+func hashBundle(src string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(h, "d %q\n", filepath.ToSlash(rel))
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "f %q %d\n", filepath.ToSlash(rel), info.Size())
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if _, err := io.CopyN(h, f, info.Size()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyBundle copies the regular files and directories in src to dst, which programs are then given instead of
+// src, so that they can never change the files of the blueprint. The copied files and directories are read-only,
+// so that programs can't change the copy that later programs are given either. Athanor run as root isn't held to
+// that, but still never changes src. Symlinks are skipped, so that programs can't read anything outside of src.
+func copyBundle(src, dst string) error {
+	var dirs []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, target)
+			return os.MkdirAll(target, 0o755)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err := os.Chmod(dir, 0o555); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,161 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+)
+
+const wasmtimeModule = "github.com/bytecodealliance/wasmtime-go/v20"
+
+// wasmtimeVersion is part of the cache key, since compiled modules can only be loaded by the wasmtime that
+// compiled them.
+var wasmtimeVersion = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == wasmtimeModule {
+				return dep.Version
+			}
+		}
+	}
+
+	return wasmtimeModule
+}()
+
+// DefaultCacheDir is where compiled modules are kept when Interpreter.CacheDir isn't set. It is empty if the user
+// has no cache directory, which disables the on-disk cache.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "athanor", "modules")
+}
+
+// moduleCache compiles each WebAssembly module once with a shared engine. Compiled modules are kept in memory for
+// the rest of the run and serialized to dir for later runs.
+type moduleCache struct {
+	engine *wasmtime.Engine
+	ticker *epochTicker
+	dir    string
+
+	mu      sync.Mutex
+	modules map[string]*cachedModule
+}
+
+// cachedModule is compiled once, by whoever loads it first. Others wait for it, while different modules are
+// compiled in parallel.
+type cachedModule struct {
+	once   sync.Once
+	module *wasmtime.Module
+	err    error
+}
+
+func newModuleCache(dir string) *moduleCache {
+	config := wasmtime.NewConfig()
+	config.SetEpochInterruption(true)
+	engine := wasmtime.NewEngineWithConfig(config)
+
+	return &moduleCache{
+		engine:  engine,
+		ticker:  &epochTicker{engine: engine},
+		dir:     dir,
+		modules: map[string]*cachedModule{},
+	}
+}
+
+// load returns the compiled module of the WebAssembly file at path.
+func (c *moduleCache) load(path string) (*wasmtime.Module, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cacheKey(data)
+
+	c.mu.Lock()
+	cached, ok := c.modules[key]
+	if !ok {
+		cached = &cachedModule{}
+		c.modules[key] = cached
+	}
+	c.mu.Unlock()
+
+	cached.once.Do(func() {
+		cached.module = c.loadFromDisk(key)
+		if cached.module != nil {
+			return
+		}
+
+		cached.module, cached.err = wasmtime.NewModule(c.engine, data)
+		if cached.err == nil {
+			c.saveToDisk(key, cached.module)
+		}
+	})
+
+	return cached.module, cached.err
+}
+
+// cacheKey identifies a module by its content and by what it is compiled with. Modules compiled without epoch
+// interruption can't be loaded by the engine, so it is part of the key too.
+func cacheKey(data []byte) string {
+	h := sha256.New()
+	h.Write(data)
+	h.Write([]byte(wasmtimeVersion + "/" + runtime.GOOS + "/" + runtime.GOARCH + "/epoch"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *moduleCache) path(key string) string {
+	return filepath.Join(c.dir, key+".cwasm")
+}
+
+// loadFromDisk returns nil if the module isn't cached or can't be loaded, in which case it is compiled again.
+func (c *moduleCache) loadFromDisk(key string) *wasmtime.Module {
+	if c.dir == "" {
+		return nil
+	}
+
+	module, err := wasmtime.NewModuleDeserializeFile(c.engine, c.path(key))
+	if err != nil {
+		return nil
+	}
+
+	return module
+}
+
+// saveToDisk ignores errors, since the cache only saves time.
+func (c *moduleCache) saveToDisk(key string, module *wasmtime.Module) {
+	if c.dir == "" {
+		return
+	}
+
+	data, err := module.Serialize()
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+
+	// Written to a temporary file first so that other runs never load a partial module.
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err != nil || closeErr != nil {
+		return
+	}
+
+	os.Rename(f.Name(), c.path(key))
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	external_ast "github.com/alchematik/athanor/ast"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadDocument reads a plain blueprint document. Files ending in .yaml or .yml are parsed as YAML, with the
+// same structure as the JSON format.
+func LoadDocument(path string) (external_ast.Blueprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return external_ast.Blueprint{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return external_ast.Blueprint{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case ".json":
+	default:
+		return external_ast.Blueprint{}, fmt.Errorf("unsupported blueprint document %s: must end in .json, .yaml or .yml", path)
+	}
+
+	var bp external_ast.Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return bp, nil
+}
@@ -0,0 +1,78 @@
+package interpreter_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	external_ast "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/interpreter"
+)
+
+func TestInterpreter_Document(t *testing.T) {
+	// Documents without a version are version 1.
+	expected := external_ast.Blueprint{
+		Version: 1,
+		Stmts: []external_ast.Stmt{
+			{
+				Type: "resource",
+				Value: external_ast.DeclareResource{
+					Name:   "my-bucket",
+					Exists: external_ast.Expr{Type: "bool", Value: external_ast.BoolLiteral{Value: true}},
+					Type:   external_ast.Expr{Type: "string", Value: external_ast.StringLiteral{Value: "bucket"}},
+				},
+			},
+		},
+	}
+
+	docs := map[string]string{
+		"blueprint.json": `{
+		  "stmts": [
+		    {
+		      "type": "resource",
+		      "value": {
+		        "name": "my-bucket",
+		        "exists": {"type": "bool", "value": {"bool_literal": true}},
+		        "type": {"type": "string", "value": {"string_literal": "bucket"}}
+		      }
+		    }
+		  ]
+		}`,
+		"blueprint.yaml": `
+stmts:
+  - type: resource
+    value:
+      name: my-bucket
+      exists:
+        type: bool
+        value:
+          bool_literal: true
+      type:
+        type: string
+        value:
+          string_literal: bucket
+`,
+	}
+
+	in := &interpreter.Interpreter{}
+	for name, doc := range docs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(doc), 0o644))
+
+			bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+				Document: external_ast.BlueprintSourceDocument{Path: path},
+			}, nil)
+			require.NoError(t, err)
+			require.Equal(t, expected, bp)
+		})
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{Inline: &expected}, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, bp)
+	})
+}
@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	external_ast "github.com/alchematik/athanor/ast"
+)
+
+// ExecInterpreter runs blueprint programs on the host, for blueprints that are generated by tools that can't
+// target WebAssembly. The program gets the input of the build as a JSON object on stdin and writes the blueprint
+// as JSON to stdout. Everything it writes to stderr is logged.
+//
+// Programs aren't sandboxed: they run with the permissions and environment of Athanor, so only exec blueprints
+// that you trust. Interpreter refuses exec sources below WebAssembly blueprints unless AllowExec is set.
+type ExecInterpreter struct {
+	Logger *slog.Logger
+	// Timeout is how long a program may run. DefaultTimeout is used if it isn't set.
+	Timeout time.Duration
+}
+
+func (it *ExecInterpreter) InterpretBlueprint(source external_ast.BlueprintSource, input map[string]any) (external_ast.Blueprint, error) {
+	if source.Exec.Command == "" {
+		return external_ast.Blueprint{}, fmt.Errorf("unsupported blueprint source %s: must be an exec source", source)
+	}
+
+	bp, err := it.interpret(source.Exec, input)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	return external_ast.ResolveSources(bp, source.Dir(".")), nil
+}
+
+func (it *ExecInterpreter) interpret(source external_ast.BlueprintSourceExec, input map[string]any) (external_ast.Blueprint, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("encoding input of blueprint %s: %w", source.Command, err)
+	}
+
+	timeout := it.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Relative commands are relative to the working directory, like other sources, but the program runs in
+	// source.Dir.
+	command := source.Command
+	if filepath.Base(command) != command && !filepath.IsAbs(command) {
+		if command, err = filepath.Abs(command); err != nil {
+			return external_ast.Blueprint{}, err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, source.Args...)
+	cmd.Dir = source.Dir
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children of the program can keep its output open after it is killed.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+
+	lines := splitLines(stderr.String())
+	logger := orDiscard(it.Logger)
+	for _, line := range lines {
+		logger.Info("blueprint output", "blueprint", source.Command, "stream", "stderr", "line", line)
+	}
+
+	if ctx.Err() != nil {
+		return external_ast.Blueprint{}, &LimitError{Path: source.Command, Limit: "time", Value: timeout.String()}
+	}
+	if err != nil {
+		failed := fmt.Errorf("failed: %w", err)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			failed = fmt.Errorf("exited with status %d", exitErr.ExitCode())
+		}
+
+		if len(lines) > 0 {
+			return external_ast.Blueprint{}, fmt.Errorf("blueprint %s %s, stderr:\n%s", source.Command, failed, tail(lines))
+		}
+
+		return external_ast.Blueprint{}, fmt.Errorf("blueprint %s %s", source.Command, failed)
+	}
+
+	var bp external_ast.Blueprint
+	if err := json.Unmarshal(stdout.Bytes(), &bp); err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("parsing blueprint from %s: %w", source.Command, err)
+	}
+
+	return bp, nil
+}
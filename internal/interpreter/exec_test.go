@@ -0,0 +1,73 @@
+package interpreter_test
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	external_ast "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/interpreter"
+)
+
+func TestInterpreter_Exec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the blueprint program")
+	}
+
+	source := external_ast.BlueprintSource{
+		Exec: external_ast.BlueprintSourceExec{Command: "sh", Args: []string{"testdata/exec/blueprint.sh"}},
+	}
+	in := &interpreter.Interpreter{}
+
+	t.Run("blueprint", func(t *testing.T) {
+		bp, err := in.InterpretBlueprint(source, map[string]any{"name": "my-bucket"})
+		require.NoError(t, err)
+		require.Equal(t, []external_ast.Stmt{
+			{
+				Type: "resource",
+				Value: external_ast.DeclareResource{
+					Name:   "my-bucket",
+					Exists: external_ast.Expr{Type: "bool", Value: external_ast.BoolLiteral{Value: true}},
+					Type:   external_ast.Expr{Type: "string", Value: external_ast.StringLiteral{Value: "bucket"}},
+				},
+			},
+		}, bp.Stmts)
+	})
+
+	t.Run("runs in its directory", func(t *testing.T) {
+		relative := external_ast.BlueprintSource{
+			Exec: external_ast.BlueprintSourceExec{Command: "sh", Args: []string{"blueprint.sh"}, Dir: "testdata/exec"},
+		}
+		bp, err := in.InterpretBlueprint(relative, map[string]any{"name": "my-bucket"})
+		require.NoError(t, err)
+		require.Len(t, bp.Stmts, 1)
+	})
+
+	t.Run("relative to the declaring blueprint", func(t *testing.T) {
+		// Run from the directory of the test, not the one of the blueprint.
+		root, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			Document: external_ast.BlueprintSourceDocument{Path: "testdata/exec/root.json"},
+		}, nil)
+		require.NoError(t, err)
+
+		generated := root.Stmts[0].Value.(external_ast.DeclareBuild).BlueprintSource
+		bp, err := in.InterpretBlueprint(generated, map[string]any{"name": "relative"})
+		require.NoError(t, err)
+		require.Equal(t, "relative", bp.Stmts[0].Value.(external_ast.DeclareResource).Name)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, err := in.InterpretBlueprint(source, map[string]any{"name": "fail"})
+		require.EqualError(t, err, "blueprint sh exited with status 3, stderr:\nrefusing to build")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		slow := external_ast.BlueprintSource{
+			Exec: external_ast.BlueprintSourceExec{Command: "sh", Args: []string{"-c", "sleep 5"}},
+		}
+		_, err := (&interpreter.ExecInterpreter{Timeout: 50 * time.Millisecond}).InterpretBlueprint(slow, nil)
+		require.EqualError(t, err, "blueprint sh exceeded the time limit of 50ms")
+	})
+}
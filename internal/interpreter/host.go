@@ -0,0 +1,232 @@
+package interpreter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+)
+
+// HostModule is the module that blueprint programs import host functions from. The functions are:
+//
+//	input(buf, buf_len) -> len
+//	env(name, name_len, buf, buf_len) -> len
+//	read_file(path, path_len, buf, buf_len) -> len
+//	log(level, msg, msg_len, attrs, attrs_len)
+//
+// Strings and buffers are passed as a pointer and a length in the program's memory. Functions that return data
+// copy it into buf if it fits and return its full length either way, so that the program can call them again
+// with a bigger buffer. They return HostNotFound or HostNotAllowed instead if there is no data to return.
+//
+// input returns the build's input as JSON. env returns the value of an environment variable if it is in
+// Interpreter.AllowedEnv. read_file returns the contents of a regular file in the directory of the blueprint
+// program, given a path relative to it. log logs msg at the slog level with the attributes in attrs, a JSON object.
+const HostModule = "athanor"
+
+const (
+	// HostNotFound is returned when an environment variable isn't set or a file doesn't exist.
+	HostNotFound int32 = -1
+	// HostNotAllowed is returned when an environment variable isn't allowed or a path is outside of the directory
+	// of the blueprint program, after following symlinks, or isn't a regular file.
+	HostNotAllowed int32 = -2
+)
+
+// host serves the host functions to the blueprint program at path.
+type host struct {
+	path       string
+	input      []byte
+	allowedEnv []string
+	logger     *slog.Logger
+}
+
+func (h *host) define(linker *wasmtime.Linker) error {
+	funcs := map[string]any{
+		"input":     h.readInput,
+		"env":       h.env,
+		"read_file": h.readFile,
+		"log":       h.log,
+	}
+	for name, f := range funcs {
+		if err := linker.FuncWrap(HostModule, name, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *host) readInput(caller *wasmtime.Caller, buf, bufLen int32) (int32, *wasmtime.Trap) {
+	return write(caller, buf, bufLen, h.input)
+}
+
+func (h *host) env(caller *wasmtime.Caller, name, nameLen, buf, bufLen int32) (int32, *wasmtime.Trap) {
+	n, trap := read(caller, name, nameLen)
+	if trap != nil {
+		return 0, trap
+	}
+
+	if !slices.Contains(h.allowedEnv, string(n)) {
+		return HostNotAllowed, nil
+	}
+
+	v, ok := os.LookupEnv(string(n))
+	if !ok {
+		return HostNotFound, nil
+	}
+
+	return write(caller, buf, bufLen, []byte(v))
+}
+
+func (h *host) readFile(caller *wasmtime.Caller, path, pathLen, buf, bufLen int32) (int32, *wasmtime.Trap) {
+	p, trap := read(caller, path, pathLen)
+	if trap != nil {
+		return 0, trap
+	}
+
+	data, err := readLocalFile(filepath.Dir(h.path), string(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return HostNotFound, nil
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return HostNotAllowed, nil
+	}
+	if err != nil {
+		return 0, wasmtime.NewTrap(err.Error())
+	}
+
+	return write(caller, buf, bufLen, data)
+}
+
+// readLocalFile reads the regular file at path, relative to dir. Paths that lead out of dir, including through
+// symlinks, and files that aren't regular, such as FIFOs that would block the read, return fs.ErrPermission.
+func readLocalFile(dir, path string) ([]byte, error) {
+	if !filepath.IsLocal(path) {
+		return nil, fs.ErrPermission
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(root, target); err != nil || !filepath.IsLocal(rel) {
+		return nil, fs.ErrPermission
+	}
+
+	// Checked before opening, since opening a FIFO blocks until it has a writer.
+	if info, err := os.Stat(target); err != nil {
+		return nil, err
+	} else if !info.Mode().IsRegular() {
+		return nil, fs.ErrPermission
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The file may have been replaced since it was checked.
+	if info, err := f.Stat(); err != nil {
+		return nil, err
+	} else if !info.Mode().IsRegular() {
+		return nil, fs.ErrPermission
+	}
+
+	return io.ReadAll(f)
+}
+
+func (h *host) log(caller *wasmtime.Caller, level, msg, msgLen, attrs, attrsLen int32) *wasmtime.Trap {
+	m, trap := read(caller, msg, msgLen)
+	if trap != nil {
+		return trap
+	}
+
+	a, trap := read(caller, attrs, attrsLen)
+	if trap != nil {
+		return trap
+	}
+
+	args := []any{"blueprint", h.path}
+	if len(a) > 0 {
+		var fields map[string]any
+		if err := json.Unmarshal(a, &fields); err != nil {
+			return wasmtime.NewTrap("log attributes must be a JSON object: " + err.Error())
+		}
+
+		for _, k := range sortedKeys(fields) {
+			args = append(args, k, fields[k])
+		}
+	}
+
+	h.logger.Log(context.Background(), slog.Level(level), string(m), args...)
+	return nil
+}
+
+// memory returns the memory of the program calling a host function.
+func memory(caller *wasmtime.Caller) ([]byte, *wasmtime.Trap) {
+	export := caller.GetExport("memory")
+	if export == nil || export.Memory() == nil {
+		return nil, wasmtime.NewTrap("blueprint program doesn't export its memory")
+	}
+
+	return export.Memory().UnsafeData(caller), nil
+}
+
+// read returns a copy of the n bytes at ptr in the memory of the program.
+func read(caller *wasmtime.Caller, ptr, n int32) ([]byte, *wasmtime.Trap) {
+	mem, trap := memory(caller)
+	if trap != nil {
+		return nil, trap
+	}
+
+	if ptr < 0 || n < 0 || int64(ptr)+int64(n) > int64(len(mem)) {
+		return nil, wasmtime.NewTrap("host function argument out of bounds")
+	}
+
+	return slices.Clone(mem[ptr : ptr+n]), nil
+}
+
+// write copies data to the buffer of n bytes at ptr in the memory of the program if it fits, and returns its length.
+func write(caller *wasmtime.Caller, ptr, n int32, data []byte) (int32, *wasmtime.Trap) {
+	if len(data) > math.MaxInt32 {
+		return 0, wasmtime.NewTrap("host function result is too large")
+	}
+	if int64(len(data)) > int64(n) {
+		return int32(len(data)), nil
+	}
+
+	mem, trap := memory(caller)
+	if trap != nil {
+		return 0, trap
+	}
+
+	if ptr < 0 || n < 0 || int64(ptr)+int64(n) > int64(len(mem)) {
+		return 0, wasmtime.NewTrap("host function argument out of bounds")
+	}
+
+	copy(mem[ptr:], data)
+	return int32(len(data)), nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
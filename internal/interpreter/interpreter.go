@@ -1,76 +1,127 @@
 package interpreter
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	external_ast "github.com/alchematik/athanor/ast"
-
-	"github.com/bytecodealliance/wasmtime-go/v20"
 )
 
+// Interpreter turns a blueprint source into a blueprint, picking how based on the kind of source.
 type Interpreter struct {
 	Logger *slog.Logger
+
+	// CacheDir is where compiled WebAssembly modules are kept between runs. DefaultCacheDir is used if it is
+	// empty.
+	CacheDir string
+
+	// Timeout is how long a WebAssembly blueprint program may run. DefaultTimeout is used if it isn't set.
+	Timeout time.Duration
+	// MemoryLimit is how many bytes of linear memory a WebAssembly blueprint program may use. DefaultMemoryLimit
+	// is used if it isn't set.
+	MemoryLimit int64
+	// AllowedEnv are the names of the environment variables that blueprint programs may read.
+	AllowedEnv []string
+	// AllowExec lets exec sources run below WebAssembly blueprint programs, including those of packages, however
+	// deep. Those run on the host, outside of the sandbox, so they are refused unless it is set.
+	AllowExec bool
+
+	// PackageDir is the directory of package archives that package sources are found in.
+	PackageDir string
+	// PackageCacheDir is where package archives are unpacked. DefaultPackageCacheDir is used if it is empty.
+	PackageCacheDir string
+	// LockFile is the path of the lock file that pins the versions of packages. Packages aren't locked if it is
+	// empty.
+	LockFile string
+
+	once    sync.Once
+	modules *moduleCache
+
+	lockOnce sync.Once
+	locked   *lock
+
+	bundles bundleCache
 }
 
-func (it *Interpreter) InterpretBlueprint(source external_ast.BlueprintSource, input map[string]any) (external_ast.Blueprint, error) {
-	engine := wasmtime.NewEngine()
-	module, err := wasmtime.NewModuleFromFile(engine, source.LocalFile.Path)
-	if err != nil {
-		return external_ast.Blueprint{}, err
-	}
+// Close removes the copies of bundles that were made for the programs it ran.
+func (it *Interpreter) Close() error {
+	return it.bundles.close()
+}
 
-	linker := wasmtime.NewLinker(engine)
-	if err := linker.DefineWasi(); err != nil {
-		return external_ast.Blueprint{}, err
-	}
+// moduleCache is shared by every blueprint interpreted with it, so that each module is only compiled once.
+func (it *Interpreter) moduleCache() *moduleCache {
+	it.once.Do(func() {
+		dir := it.CacheDir
+		if dir == "" {
+			dir = DefaultCacheDir()
+		}
 
-	wasiConfig := wasmtime.NewWasiConfig()
+		it.modules = newModuleCache(dir)
+	})
 
-	dir, err := os.MkdirTemp("", "")
-	if err != nil {
-		return external_ast.Blueprint{}, err
-	}
-	defer os.RemoveAll(dir)
+	return it.modules
+}
 
-	if err := wasiConfig.PreopenDir(dir, "/"); err != nil {
-		return external_ast.Blueprint{}, err
-	}
+// lock is shared by every blueprint interpreted with it, so that the lock file is only read once.
+func (it *Interpreter) lock() *lock {
+	it.lockOnce.Do(func() {
+		it.locked = &lock{path: it.LockFile}
+	})
 
-	store := wasmtime.NewStore(engine)
-	store.SetWasi(wasiConfig)
+	return it.locked
+}
 
-	instance, err := linker.Instantiate(store, module)
-	if err != nil {
-		return external_ast.Blueprint{}, err
+func (it *Interpreter) InterpretBlueprint(source external_ast.BlueprintSource, input map[string]any) (external_ast.Blueprint, error) {
+	kinds := source.Kinds()
+	if len(kinds) == 0 {
+		return external_ast.Blueprint{}, errors.New("must provide a blueprint source")
+	}
+	if len(kinds) > 1 {
+		return external_ast.Blueprint{}, fmt.Errorf("must provide only one blueprint source, got %s", strings.Join(kinds, ", "))
 	}
 
-	nom := instance.GetFunc(store, "_start")
-	_, err = nom.Call(store)
-	if err != nil {
-		var wasmtimeError *wasmtime.Error
-		if errors.As(err, &wasmtimeError) {
-			st, ok := wasmtimeError.ExitStatus()
-			if ok && st != 0 {
-				return external_ast.Blueprint{}, fmt.Errorf("non-0 exit status: %d", st)
-			}
-		} else {
+	var bp external_ast.Blueprint
+	var err error
+	switch kinds[0] {
+	case "local_file":
+		bp, err = it.interpretWasm(source.LocalFile, input)
+	case "document":
+		bp, err = LoadDocument(source.Document.Path)
+	case "exec":
+		if source.Sandboxed && !it.AllowExec {
+			return external_ast.Blueprint{}, fmt.Errorf("blueprint %s runs on the host, but is declared below a WebAssembly blueprint: exec sources there must be allowed with --allow-exec", source.Exec.Command)
+		}
+
+		exec := &ExecInterpreter{Logger: it.Logger, Timeout: it.Timeout}
+		return exec.InterpretBlueprint(source, input)
+	case "package":
+		bp, err := it.interpretPackage(source.Package, input)
+		if err != nil {
 			return external_ast.Blueprint{}, err
 		}
-	}
 
-	data, err := os.ReadFile(filepath.Join(dir, "blueprint.json"))
+		return external_ast.Sandbox(bp), nil
+	case "inline":
+		bp = *source.Inline
+	default:
+		return external_ast.Blueprint{}, fmt.Errorf("unsupported blueprint source: %s", kinds[0])
+	}
 	if err != nil {
 		return external_ast.Blueprint{}, err
 	}
 
-	var bp external_ast.Blueprint
-	if err := json.Unmarshal(data, &bp); err != nil {
-		return external_ast.Blueprint{}, err
+	// Builds declare their sources relative to the blueprint that declares them, unless it was written before
+	// external_ast.RelativeSourcesVersion. Inline blueprints were resolved along with the blueprint that declares
+	// them, and are left as they are.
+	bp = external_ast.ResolveSources(bp, source.Dir("."))
+
+	// Everything below a WebAssembly blueprint is sandboxed, whatever kind of source leads to it.
+	if source.Sandboxed || kinds[0] == "local_file" {
+		bp = external_ast.Sandbox(bp)
 	}
 
 	return bp, nil
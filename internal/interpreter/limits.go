@@ -0,0 +1,136 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+)
+
+const (
+	// DefaultTimeout is how long a blueprint program may run when Interpreter.Timeout isn't set.
+	DefaultTimeout = 30 * time.Second
+	// DefaultMemoryLimit is how many bytes of linear memory a blueprint program may use when
+	// Interpreter.MemoryLimit isn't set.
+	DefaultMemoryLimit = 512 << 20
+
+	// wasmPageSize is the unit that WebAssembly memory grows by.
+	wasmPageSize = 64 << 10
+
+	// epochTick is how often the engine's epoch advances while programs run, which is how precisely timeouts
+	// are enforced.
+	epochTick = 10 * time.Millisecond
+)
+
+// LimitError is returned when a blueprint program is stopped for going over one of its limits.
+type LimitError struct {
+	// Path is the path of the blueprint program.
+	Path string
+	// Limit is the name of the limit that was hit, either "time" or "memory".
+	Limit string
+	// Value describes the limit that was hit.
+	Value string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("blueprint %s exceeded the %s limit of %s", e.Path, e.Limit, e.Value)
+}
+
+func (it *Interpreter) timeout() time.Duration {
+	if it.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return it.Timeout
+}
+
+func (it *Interpreter) memoryLimit() int64 {
+	if it.MemoryLimit <= 0 {
+		return DefaultMemoryLimit
+	}
+
+	return it.MemoryLimit
+}
+
+// limit applies the timeout and memory limit of the interpreter to store.
+func (it *Interpreter) limit(store *wasmtime.Store) {
+	ticks := uint64((it.timeout() + epochTick - 1) / epochTick)
+	store.SetEpochDeadline(ticks)
+	store.Limiter(it.memoryLimit(), -1, -1, -1, -1)
+}
+
+// limitError returns the limit that err, returned by running the program at path, is due to, or nil if it isn't
+// due to one.
+func (it *Interpreter) limitError(path string, err error, store *wasmtime.Store, instance *wasmtime.Instance) error {
+	var trap *wasmtime.Trap
+	if errors.As(err, &trap) {
+		if code := trap.Code(); code != nil && *code == wasmtime.Interrupt {
+			return &LimitError{Path: path, Limit: "time", Value: it.timeout().String()}
+		}
+	}
+
+	// Programs aren't told why memory can't grow, so they fail in their own way when it can't. A failing program
+	// is taken to have hit the limit only if its memory can't grow by even one more page. Programs that are refused
+	// a bigger grow before that are reported with their own failure instead.
+	export := instance.GetExport(store, "memory")
+	if export == nil || export.Memory() == nil {
+		return nil
+	}
+
+	limit := it.memoryLimit()
+	if size := export.Memory().DataSize(store); size > math.MaxInt64-wasmPageSize || int64(size)+wasmPageSize > limit {
+		return &LimitError{Path: path, Limit: "memory", Value: fmt.Sprintf("%d bytes", limit)}
+	}
+
+	return nil
+}
+
+// epochTicker advances the epoch of an engine while any program runs with it, so that stores with an epoch
+// deadline are interrupted once it passes.
+type epochTicker struct {
+	engine *wasmtime.Engine
+
+	mu      sync.Mutex
+	running int
+	stop    chan struct{}
+}
+
+// start must be matched by a call to done once the program finishes.
+func (t *epochTicker) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.running++
+	if t.running > 1 {
+		return
+	}
+
+	stop := make(chan struct{})
+	t.stop = stop
+	go func() {
+		ticker := time.NewTicker(epochTick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				t.engine.IncrementEpoch()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (t *epochTicker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.running--
+	if t.running == 0 {
+		close(t.stop)
+	}
+}
@@ -0,0 +1,144 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// LockFile pins the versions of the blueprint packages that builds use, and the checksums of their archives, so
+// that every run uses the same ones.
+type LockFile struct {
+	Packages []LockedPackage `json:"packages"`
+}
+
+type LockedPackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+// lock is the lock file at path, loaded on first use. Packages resolved for the first time are added to it and
+// saved right away.
+type lock struct {
+	path string
+
+	once sync.Once
+	err  error
+
+	mu   sync.Mutex
+	file LockFile
+}
+
+func (l *lock) load() error {
+	l.once.Do(func() {
+		if l.path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(l.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		if err := json.Unmarshal(data, &l.file); err != nil {
+			l.err = fmt.Errorf("parsing lock file %s: %w", l.path, err)
+		}
+	})
+
+	return l.err
+}
+
+// get returns the locked version of the package, or its newest locked version if version is empty.
+func (l *lock) get(name, version string) (LockedPackage, bool, error) {
+	if err := l.load(); err != nil {
+		return LockedPackage{}, false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var found LockedPackage
+	ok := false
+	for _, p := range l.file.Packages {
+		if p.Name != name || (version != "" && p.Version != version) {
+			continue
+		}
+		if !ok || CompareVersions(p.Version, found.Version) > 0 {
+			found = p
+			ok = true
+		}
+	}
+
+	return found, ok, nil
+}
+
+// add locks the package and saves the lock file.
+func (l *lock) add(p LockedPackage) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, locked := range l.file.Packages {
+		if locked.Name == p.Name && locked.Version == p.Version {
+			return nil
+		}
+	}
+
+	l.file.Packages = append(l.file.Packages, p)
+	sort.Slice(l.file.Packages, func(i, j int) bool {
+		a, b := l.file.Packages[i], l.file.Packages[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+
+		return CompareVersions(a.Version, b.Version) < 0
+	})
+
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(l.path, append(data, '\n'))
+}
+
+// writeFileAtomic writes to a temporary file first, so that readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+)
+
+// stderrTailLines is how many of the last lines of stderr are included in the error of a failed program.
+const stderrTailLines = 20
+
+func (it *Interpreter) logger() *slog.Logger {
+	return orDiscard(it.Logger)
+}
+
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	return logger
+}
+
+// output captures what a blueprint program writes to stdout and stderr. The files are kept outside of the
+// directory the program can see.
+type output struct {
+	dir string
+}
+
+func newOutput() (*output, error) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{dir: dir}, nil
+}
+
+func (o *output) path(stream string) string {
+	return filepath.Join(o.dir, stream)
+}
+
+func (o *output) capture(config *wasmtime.WasiConfig) error {
+	if err := config.SetStdoutFile(o.path("stdout")); err != nil {
+		return err
+	}
+
+	return config.SetStderrFile(o.path("stderr"))
+}
+
+// log logs every line the program at path wrote.
+func (o *output) log(logger *slog.Logger, path string) {
+	for _, stream := range []string{"stdout", "stderr"} {
+		for _, line := range o.lines(stream) {
+			logger.Info("blueprint output", "blueprint", path, "stream", stream, "line", line)
+		}
+	}
+}
+
+// stderrTail returns the last lines of stderr, or an empty string if nothing was written to it.
+func (o *output) stderrTail() string {
+	return tail(o.lines("stderr"))
+}
+
+func (o *output) lines(stream string) []string {
+	data, err := os.ReadFile(o.path(stream))
+	if err != nil {
+		return nil
+	}
+
+	return splitLines(string(data))
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+func tail(lines []string) string {
+	if len(lines) > stderrTailLines {
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (o *output) close() {
+	os.RemoveAll(o.dir)
+}
@@ -0,0 +1,376 @@
+package interpreter
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	external_ast "github.com/alchematik/athanor/ast"
+)
+
+const (
+	// PackageExt is the extension of package archives. The archive of version v of package name is
+	// <name>-<v>.tar.gz.
+	PackageExt = ".tar.gz"
+
+	// ManifestFile describes the package. It is at the root of the archive.
+	ManifestFile = "manifest.json"
+
+	// PackageBundleDir is the directory of the archive that is given to the program as its bundle.
+	PackageBundleDir = "bundle"
+)
+
+// Manifest describes a blueprint package.
+type Manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Module is the path of the WebAssembly program in the archive. It is main.wasm if it isn't set.
+	Module string `json:"module"`
+	// Inputs are the inputs of the blueprint, for programs that don't declare them themselves.
+	Inputs []external_ast.Input `json:"inputs"`
+}
+
+// DefaultPackageCacheDir is where packages are unpacked when Interpreter.PackageCacheDir isn't set.
+func DefaultPackageCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "athanor", "packages")
+	}
+
+	return filepath.Join(dir, "athanor", "packages")
+}
+
+// interpretPackage finds the archive of the package in PackageDir, checks it against the source and the lock
+// file, and runs the program it contains.
+func (it *Interpreter) interpretPackage(source external_ast.BlueprintSourcePackage, input map[string]any) (external_ast.Blueprint, error) {
+	if it.PackageDir == "" {
+		return external_ast.Blueprint{}, fmt.Errorf("no package directory to find package %s in", source)
+	}
+
+	if !isFileName(source.Name) {
+		return external_ast.Blueprint{}, fmt.Errorf("invalid package name %q", source.Name)
+	}
+
+	version, locked, err := it.resolveVersion(source)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+	// The version can come from the build or the lock file, and is part of the path of the archive as well.
+	if !isFileName(version) {
+		return external_ast.Blueprint{}, fmt.Errorf("invalid version %q of package %s", version, source.Name)
+	}
+	id := source.Name + "@" + version
+
+	archive := filepath.Join(it.PackageDir, source.Name+"-"+version+PackageExt)
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("reading package %s: %w", id, err)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+	if source.Checksum != "" && source.Checksum != checksum {
+		return external_ast.Blueprint{}, fmt.Errorf("checksum mismatch for package %s: archive has %s, but the build expects %s", id, checksum, source.Checksum)
+	}
+	if locked.Checksum != "" && locked.Checksum != checksum {
+		return external_ast.Blueprint{}, fmt.Errorf("checksum mismatch for package %s: archive has %s, but the lock file has %s", id, checksum, locked.Checksum)
+	}
+
+	dir, err := it.unpack(data, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("unpacking package %s: %w", id, err)
+	}
+
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("package %s: %w", id, err)
+	}
+	if manifest.Name != source.Name || manifest.Version != version {
+		return external_ast.Blueprint{}, fmt.Errorf("package %s: manifest is for %s@%s", id, manifest.Name, manifest.Version)
+	}
+
+	if err := it.lock().add(LockedPackage{Name: source.Name, Version: version, Checksum: checksum}); err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("locking package %s: %w", id, err)
+	}
+
+	module := manifest.Module
+	if module == "" {
+		module = "main.wasm"
+	}
+	if !filepath.IsLocal(module) {
+		return external_ast.Blueprint{}, fmt.Errorf("package %s: module %q is outside of the package", id, module)
+	}
+
+	local := external_ast.BlueprintSourceLocalFile{Path: filepath.Join(dir, module)}
+	if info, err := os.Stat(filepath.Join(dir, PackageBundleDir)); err == nil && info.IsDir() {
+		local.Bundle = filepath.Join(dir, PackageBundleDir)
+	}
+
+	bp, err := it.interpretWasm(local, input)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+	if bp.Inputs == nil {
+		bp.Inputs = manifest.Inputs
+	}
+
+	// Builds in the package declare their sources relative to the package.
+	return external_ast.ResolveSources(bp, dir), nil
+}
+
+// resolveVersion returns the version of the package to use and its lock entry, if it is locked. Packages
+// without a version use the newest locked version, or else the newest archive in PackageDir.
+func (it *Interpreter) resolveVersion(source external_ast.BlueprintSourcePackage) (string, LockedPackage, error) {
+	locked, ok, err := it.lock().get(source.Name, source.Version)
+	if err != nil {
+		return "", LockedPackage{}, err
+	}
+	if ok {
+		return locked.Version, locked, nil
+	}
+	if source.Version != "" {
+		return source.Version, LockedPackage{}, nil
+	}
+
+	entries, err := os.ReadDir(it.PackageDir)
+	if err != nil {
+		return "", LockedPackage{}, err
+	}
+
+	var newest string
+	for _, e := range entries {
+		v, ok := strings.CutPrefix(e.Name(), source.Name+"-")
+		if !ok || e.IsDir() {
+			continue
+		}
+		v, ok = strings.CutSuffix(v, PackageExt)
+		// Archives of other packages whose names start with this one, like gcp-network for gcp, don't have a
+		// version after the prefix.
+		if !ok || !isVersion(v) {
+			continue
+		}
+
+		if newest == "" || CompareVersions(v, newest) > 0 {
+			newest = v
+		}
+	}
+	if newest == "" {
+		return "", LockedPackage{}, fmt.Errorf("no archives of package %s in %s", source.Name, it.PackageDir)
+	}
+
+	return newest, LockedPackage{}, nil
+}
+
+// unpack extracts the archive into the package cache, under its checksum, unless it is there already.
+func (it *Interpreter) unpack(data []byte, sum string) (string, error) {
+	cacheDir := it.PackageCacheDir
+	if cacheDir == "" {
+		cacheDir = DefaultPackageCacheDir()
+	}
+
+	dir := filepath.Join(cacheDir, sum)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return "", err
+	}
+
+	// Extracted next to where it goes, so that other runs never see a partial package.
+	tmp, err := os.MkdirTemp(cacheDir, sum+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extract(data, tmp); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another run unpacked it first.
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return dir, nil
+		}
+
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// extract writes the regular files and directories of a gzipped tarball to dir. Entries with paths outside of
+// dir are rejected, and other kinds of entries, such as links, are skipped.
+func extract(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(strings.TrimPrefix(header.Name, "./"))
+		if name == "" || name == "." {
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %q is outside of the package", header.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Manifest{}, fmt.Errorf("archive has no %s", ManifestFile)
+	}
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("parsing %s: %w", ManifestFile, err)
+	}
+
+	return m, nil
+}
+
+// isFileName reports whether name can be used in the name of a file in a directory, without reaching out of it.
+func isFileName(name string) bool {
+	return filepath.IsLocal(name) && !strings.ContainsAny(name, "/"+string(filepath.Separator))
+}
+
+// isVersion reports whether v is a version like v1.2 or 1.2.0-rc.1: numbers separated by dots, after an optional
+// "v". Like in semantic versioning, only versions with three numbers can have a pre-release or build suffix, so
+// that the archives of packages like gcp-v2 aren't taken for versions of gcp.
+func isVersion(v string) bool {
+	v = strings.TrimPrefix(v, "v")
+	core, suffix := v, false
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		core, suffix = v[:i], true
+	}
+
+	parts := strings.Split(core, ".")
+	if suffix && len(parts) != 3 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompareVersions orders versions like v1.10.0 by their numbers, with missing numbers taken as 0, so that v1.2 and
+// 1.2.0 are the same version. Like in semantic versioning, build suffixes are ignored and a pre-release like
+// 1.2.0-rc.1 comes before the version without one.
+func CompareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+
+	as, bs := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		an, bn := "0", "0"
+		if i < len(as) {
+			an = as[i]
+		}
+		if i < len(bs) {
+			bn = bs[i]
+		}
+		if c := compareIdentifiers(an, bn); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	as, bs = strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifiers(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	return len(as) - len(bs)
+}
+
+// splitVersion returns the numbers of a version and its pre-release, without the "v" and the build suffix.
+func splitVersion(v string) (string, string) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, _ := strings.Cut(v, "-")
+	return core, pre
+}
+
+// compareIdentifiers compares numbers numerically and other identifiers as strings. Numbers come first.
+func compareIdentifiers(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,208 @@
+package interpreter_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	external_ast "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/interpreter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpreter_Package(t *testing.T) {
+	wasm, err := os.ReadFile(buildWasm(t, "./testdata/bundle"))
+	require.NoError(t, err)
+
+	packageDir := t.TempDir()
+	archive := func(version, region string) string {
+		manifest, err := json.Marshal(interpreter.Manifest{
+			Name:    "regional",
+			Version: version,
+			Inputs:  []external_ast.Input{{Name: "project", Type: "string"}},
+		})
+		require.NoError(t, err)
+
+		return writeArchive(t, filepath.Join(packageDir, "regional-"+version+interpreter.PackageExt), map[string]string{
+			interpreter.ManifestFile: string(manifest),
+			"main.wasm":              string(wasm),
+			"bundle/region.txt":      region,
+		})
+	}
+	older := archive("1.2.0", "us-east1")
+	newer := archive("1.10.0", "europe-west1")
+
+	region := func(bp external_ast.Blueprint) string {
+		require.Len(t, bp.Stmts, 1)
+		return bp.Stmts[0].Value.(external_ast.DeclareOutput).Value.Value.(external_ast.StringLiteral).Value
+	}
+
+	t.Run("newest version is used and locked", func(t *testing.T) {
+		lockFile := filepath.Join(t.TempDir(), "athanor.lock")
+		in := &interpreter.Interpreter{CacheDir: t.TempDir(), PackageDir: packageDir, PackageCacheDir: t.TempDir(), LockFile: lockFile}
+
+		bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			Package: external_ast.BlueprintSourcePackage{Name: "regional"},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "europe-west1", region(bp))
+		require.Equal(t, []external_ast.Input{{Name: "project", Type: "string"}}, bp.Inputs)
+
+		data, err := os.ReadFile(lockFile)
+		require.NoError(t, err)
+		var lock interpreter.LockFile
+		require.NoError(t, json.Unmarshal(data, &lock))
+		require.Equal(t, []interpreter.LockedPackage{{Name: "regional", Version: "1.10.0", Checksum: newer}}, lock.Packages)
+	})
+
+	t.Run("locked version is used", func(t *testing.T) {
+		lockFile := filepath.Join(t.TempDir(), "athanor.lock")
+		data, err := json.Marshal(interpreter.LockFile{Packages: []interpreter.LockedPackage{
+			{Name: "regional", Version: "1.2.0", Checksum: older},
+		}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(lockFile, data, 0o644))
+
+		in := &interpreter.Interpreter{CacheDir: t.TempDir(), PackageDir: packageDir, PackageCacheDir: t.TempDir(), LockFile: lockFile}
+		bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			Package: external_ast.BlueprintSourcePackage{Name: "regional"},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "us-east1", region(bp))
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		in := &interpreter.Interpreter{CacheDir: t.TempDir(), PackageDir: packageDir, PackageCacheDir: t.TempDir()}
+		_, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			Package: external_ast.BlueprintSourcePackage{Name: "regional", Version: "1.2.0", Checksum: newer},
+		}, nil)
+		require.ErrorContains(t, err, "checksum mismatch for package regional@1.2.0")
+	})
+
+	t.Run("unpacked into the cache by checksum", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		in := &interpreter.Interpreter{CacheDir: t.TempDir(), PackageDir: packageDir, PackageCacheDir: cacheDir}
+		_, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			Package: external_ast.BlueprintSourcePackage{Name: "regional", Version: "1.2.0", Checksum: older},
+		}, nil)
+		require.NoError(t, err)
+
+		region, err := os.ReadFile(filepath.Join(cacheDir, older[len("sha256:"):], "bundle", "region.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "us-east1", string(region))
+	})
+}
+
+func TestInterpreter_PackageSharedPrefix(t *testing.T) {
+	wasm, err := os.ReadFile(buildWasm(t, "./testdata/bundle"))
+	require.NoError(t, err)
+
+	packageDir := t.TempDir()
+	for name, version := range map[string]string{"gcp": "1.0.0", "gcp-network": "2.0.0", "gcp-v2": "1.5.0"} {
+		manifest, err := json.Marshal(interpreter.Manifest{Name: name, Version: version})
+		require.NoError(t, err)
+
+		writeArchive(t, filepath.Join(packageDir, name+"-"+version+interpreter.PackageExt), map[string]string{
+			interpreter.ManifestFile: string(manifest),
+			"main.wasm":              string(wasm),
+			"bundle/region.txt":      name,
+		})
+	}
+
+	for _, name := range []string{"gcp", "gcp-network", "gcp-v2"} {
+		t.Run(name, func(t *testing.T) {
+			lockFile := filepath.Join(t.TempDir(), "athanor.lock")
+			in := &interpreter.Interpreter{CacheDir: t.TempDir(), PackageDir: packageDir, PackageCacheDir: t.TempDir(), LockFile: lockFile}
+			bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+				Package: external_ast.BlueprintSourcePackage{Name: name},
+			}, nil)
+			require.NoError(t, err)
+			require.Equal(t, name, bp.Stmts[0].Value.(external_ast.DeclareOutput).Value.Value.(external_ast.StringLiteral).Value)
+		})
+	}
+}
+
+func TestInterpreter_PackageUnsafeArchive(t *testing.T) {
+	packageDir := t.TempDir()
+	writeArchive(t, filepath.Join(packageDir, "evil-1.0.0"+interpreter.PackageExt), map[string]string{
+		"../escaped": "nope",
+	})
+
+	cacheDir := t.TempDir()
+	in := &interpreter.Interpreter{PackageDir: packageDir, PackageCacheDir: cacheDir}
+	_, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+		Package: external_ast.BlueprintSourcePackage{Name: "evil", Version: "1.0.0"},
+	}, nil)
+	require.ErrorContains(t, err, `archive entry "../escaped" is outside of the package`)
+	require.NoFileExists(t, filepath.Join(filepath.Dir(cacheDir), "escaped"))
+}
+
+func TestInterpreter_PackageOutsideOfPackageDir(t *testing.T) {
+	for name, source := range map[string]external_ast.BlueprintSourcePackage{
+		"name":      {Name: "../evil", Version: "1.0.0"},
+		"version":   {Name: "evil", Version: "../../1.0.0"},
+		"separator": {Name: "evil/inner", Version: "1.0.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			in := &interpreter.Interpreter{PackageDir: t.TempDir(), PackageCacheDir: t.TempDir()}
+			_, err := in.InterpretBlueprint(external_ast.BlueprintSource{Package: source}, nil)
+			require.ErrorContains(t, err, "invalid")
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.2.0-rc.1", b: "1.2.0", want: -1},
+		{a: "1.2.0", b: "1.2.0-rc.1", want: 1},
+		{a: "1.9.0", b: "1.10.0", want: -1},
+		{a: "v1.2", b: "1.2", want: 0},
+		{a: "v1.2", b: "1.2.0", want: 0},
+		{a: "1.2.0-rc.2", b: "1.2.0-rc.10", want: -1},
+		{a: "1.2.0-rc.1", b: "1.2.0-rc.1.1", want: -1},
+		{a: "1.2.0-1", b: "1.2.0-alpha", want: -1},
+		{a: "1.2.0-rc.1", b: "1.1.9", want: 1},
+		{a: "1.2.0+build.5", b: "1.2.0", want: 0},
+	} {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			got := interpreter.CompareVersions(tt.a, tt.b)
+			switch {
+			case got < 0:
+				got = -1
+			case got > 0:
+				got = 1
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// writeArchive writes a package archive with the files to path and returns its checksum.
+func writeArchive(t *testing.T, path string, files map[string]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	sum := sha256.Sum256(buf.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	bp "github.com/alchematik/athanor/blueprint"
+)
+
+func main() {
+	region, err := os.ReadFile("/bundle/region.txt")
+	if err != nil {
+		log.Fatalf("reading region: %v", err)
+	}
+
+	// Changes to the bundle must never reach the files of the blueprint.
+	os.WriteFile("/bundle/region.txt", []byte("changed"), 0o644)
+	os.Remove("/bundle/region.txt")
+
+	blueprint := bp.Build(bp.Output("region", bp.String(string(region))))
+	if err := blueprint.Write(); err != nil {
+		log.Fatalf("error writing blueprint: %v", err)
+	}
+}
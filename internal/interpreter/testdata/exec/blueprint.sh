@@ -0,0 +1,22 @@
+#!/bin/sh
+# Declares a bucket named after the "name" input.
+name=$(sed 's/.*"name":"\([^"]*\)".*/\1/')
+if [ "$name" = "fail" ]; then
+  echo "refusing to build" >&2
+  exit 3
+fi
+
+cat <<JSON
+{
+  "stmts": [
+    {
+      "type": "resource",
+      "value": {
+        "name": "$name",
+        "exists": {"type": "bool", "value": {"bool_literal": true}},
+        "type": {"type": "string", "value": {"string_literal": "bucket"}}
+      }
+    }
+  ]
+}
+JSON
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"log"
+
+	bp "github.com/alchematik/athanor/blueprint"
+)
+
+// Declares a document, which is written next to the program by the test and declares an exec build.
+func main() {
+	blueprint := bp.Build(bp.SubBuild("doc", "./nested.json"))
+	if err := blueprint.Write(); err != nil {
+		log.Fatalf("error writing blueprint: %v", err)
+	}
+}
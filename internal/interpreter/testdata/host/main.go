@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+
+	bp "github.com/alchematik/athanor/blueprint"
+	"github.com/alchematik/athanor/blueprint/host"
+)
+
+func main() {
+	in, err := host.Input()
+	if err != nil {
+		log.Fatalf("reading input: %v", err)
+	}
+
+	region, ok, err := host.Env("REGION")
+	if err != nil || !ok {
+		log.Fatalf("reading REGION: %v, %v", ok, err)
+	}
+
+	if _, _, err := host.Env("SECRET"); !errors.Is(err, host.ErrNotAllowed) {
+		log.Fatalf("reading SECRET: %v", err)
+	}
+
+	data, err := host.ReadFile("data.txt")
+	if err != nil {
+		log.Fatalf("reading data.txt: %v", err)
+	}
+
+	if _, err := host.ReadFile("../data.txt"); !errors.Is(err, host.ErrNotAllowed) {
+		log.Fatalf("reading ../data.txt: %v", err)
+	}
+
+	for _, path := range []string{"escape.txt", "dir"} {
+		if _, err := host.ReadFile(path); !errors.Is(err, host.ErrNotAllowed) {
+			log.Fatalf("reading %s: %v", path, err)
+		}
+	}
+
+	alias, err := host.ReadFile("alias.txt")
+	if err != nil || string(alias) != string(data) {
+		log.Fatalf("reading alias.txt: %q, %v", alias, err)
+	}
+
+	host.Log(slog.LevelWarn, "hello from the blueprint", "region", region)
+
+	blueprint := bp.Build(
+		bp.Output("name", bp.String(fmt.Sprint(in["name"]))),
+		bp.Output("region", bp.String(region)),
+		bp.Output("data", bp.String(string(data))),
+	)
+
+	if err := blueprint.Write(); err != nil {
+		log.Fatalf("error writing blueprint: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	external_ast "github.com/alchematik/athanor/ast"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+)
+
+// interpretWasm runs a WebAssembly blueprint program and reads the blueprint.json it writes. The program can
+// write to / and read its bundle, if it has one, at BundleGuestPath.
+func (it *Interpreter) interpretWasm(source external_ast.BlueprintSourceLocalFile, input map[string]any) (external_ast.Blueprint, error) {
+	cache := it.moduleCache()
+	module, err := cache.load(source.Path)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	linker := wasmtime.NewLinker(cache.engine)
+	if err := linker.DefineWasi(); err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	if input == nil {
+		input = map[string]any{}
+	}
+	inputData, err := json.Marshal(input)
+	if err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("encoding input of blueprint %s: %w", source.Path, err)
+	}
+
+	h := &host{path: source.Path, input: inputData, allowedEnv: it.AllowedEnv, logger: it.logger()}
+	if err := h.define(linker); err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	wasiConfig := wasmtime.NewWasiConfig()
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := wasiConfig.PreopenDir(dir, "/"); err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	if source.Bundle != "" {
+		bundle, err := it.bundles.get(source.Bundle)
+		if err != nil {
+			return external_ast.Blueprint{}, fmt.Errorf("bundling %s for blueprint %s: %w", source.Bundle, source.Path, err)
+		}
+
+		if err := wasiConfig.PreopenDir(bundle, BundleGuestPath); err != nil {
+			return external_ast.Blueprint{}, err
+		}
+	}
+
+	out, err := newOutput()
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+	defer out.close()
+
+	if err := out.capture(wasiConfig); err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	store := wasmtime.NewStore(cache.engine)
+	store.SetWasi(wasiConfig)
+	it.limit(store)
+
+	instance, err := linker.Instantiate(store, module)
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	cache.ticker.start()
+	nom := instance.GetFunc(store, "_start")
+	_, err = nom.Call(store)
+	cache.ticker.done()
+	out.log(it.logger(), source.Path)
+	if err != nil {
+		failed := fmt.Errorf("failed: %w", err)
+		var wasmtimeError *wasmtime.Error
+		if errors.As(err, &wasmtimeError) {
+			st, ok := wasmtimeError.ExitStatus()
+			failed = nil
+			if ok && st != 0 {
+				failed = fmt.Errorf("exited with status %d", st)
+			}
+		}
+
+		if failed != nil {
+			if limitErr := it.limitError(source.Path, err, store, instance); limitErr != nil {
+				return external_ast.Blueprint{}, limitErr
+			}
+
+			if tail := out.stderrTail(); tail != "" {
+				return external_ast.Blueprint{}, fmt.Errorf("blueprint %s %s, stderr:\n%s", source.Path, failed, tail)
+			}
+
+			return external_ast.Blueprint{}, fmt.Errorf("blueprint %s %s", source.Path, failed)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "blueprint.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return external_ast.Blueprint{}, fmt.Errorf("blueprint %s exited without writing /blueprint.json", source.Path)
+	}
+	if err != nil {
+		return external_ast.Blueprint{}, err
+	}
+
+	var bp external_ast.Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return external_ast.Blueprint{}, fmt.Errorf("parsing blueprint from %s: %w", source.Path, err)
+	}
+
+	return bp, nil
+}
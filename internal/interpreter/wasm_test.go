@@ -0,0 +1,312 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytecodealliance/wasmtime-go/v20"
+	"github.com/stretchr/testify/require"
+
+	external_ast "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/interpreter"
+)
+
+func TestInterpreter_WasmModuleCache(t *testing.T) {
+	source := external_ast.BlueprintSource{
+		LocalFile: external_ast.BlueprintSourceLocalFile{Path: "../../example/gcp/sub/main.wasm"},
+	}
+	dir := t.TempDir()
+
+	in := &interpreter.Interpreter{CacheDir: dir}
+	first, err := in.InterpretBlueprint(source, nil)
+	require.NoError(t, err)
+
+	// Served from memory.
+	second, err := in.InterpretBlueprint(source, nil)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Served from disk by a new run.
+	third, err := (&interpreter.Interpreter{CacheDir: dir}).InterpretBlueprint(source, nil)
+	require.NoError(t, err)
+	require.Equal(t, first, third)
+}
+
+func TestInterpreter_WasmLimits(t *testing.T) {
+	type test struct {
+		name   string
+		wat    string
+		in     *interpreter.Interpreter
+		expect string
+	}
+
+	tests := []test{
+		{
+			name:   "time",
+			wat:    `(module (func (export "_start") (loop br 0)))`,
+			in:     &interpreter.Interpreter{Timeout: 50 * time.Millisecond},
+			expect: "exceeded the time limit of 50ms",
+		},
+		{
+			name: "memory",
+			wat: `(module
+				(memory (export "memory") 16)
+				(func (export "_start")
+					(if (i32.lt_s (memory.grow (i32.const 1)) (i32.const 0)) (then unreachable))))`,
+			in:     &interpreter.Interpreter{MemoryLimit: 1 << 20},
+			expect: "exceeded the memory limit of 1048576 bytes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := wasmtime.Wat2Wasm(test.wat)
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "main.wasm")
+			require.NoError(t, os.WriteFile(path, data, 0o644))
+
+			test.in.CacheDir = t.TempDir()
+			_, err = test.in.InterpretBlueprint(external_ast.BlueprintSource{
+				LocalFile: external_ast.BlueprintSourceLocalFile{Path: path},
+			}, nil)
+
+			var limitErr *interpreter.LimitError
+			require.ErrorAs(t, err, &limitErr)
+			require.Equal(t, test.name, limitErr.Limit)
+			require.Equal(t, "blueprint "+path+" "+test.expect, err.Error())
+		})
+	}
+}
+
+func TestInterpreter_WasmFailureNearMemoryLimit(t *testing.T) {
+	// Uses over half of its limit, but fails with room to grow.
+	data, err := wasmtime.Wat2Wasm(`(module
+		(memory (export "memory") 12)
+		(func (export "_start") unreachable))`)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "main.wasm")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	in := &interpreter.Interpreter{CacheDir: t.TempDir(), MemoryLimit: 1 << 20}
+	_, err = in.InterpretBlueprint(external_ast.BlueprintSource{
+		LocalFile: external_ast.BlueprintSourceLocalFile{Path: path},
+	}, nil)
+
+	var limitErr *interpreter.LimitError
+	require.False(t, errors.As(err, &limitErr), "%v", err)
+	require.ErrorContains(t, err, "blueprint "+path+" failed")
+}
+
+func TestInterpreter_WasmOutput(t *testing.T) {
+	type test struct {
+		name   string
+		wat    string
+		expect string
+		logged string
+	}
+
+	tests := []test{
+		{
+			name: "non-0 exit status",
+			wat: `(module
+				(import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+				(import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+				(memory (export "memory") 1)
+				(data (i32.const 16) "something broke\n")
+				(func (export "_start")
+					(i32.store (i32.const 0) (i32.const 16))
+					(i32.store (i32.const 4) (i32.const 16))
+					(drop (call $fd_write (i32.const 2) (i32.const 0) (i32.const 1) (i32.const 8)))
+					(call $proc_exit (i32.const 1))))`,
+			expect: "exited with status 1, stderr:\nsomething broke",
+			logged: `stream=stderr line="something broke"`,
+		},
+		{
+			name:   "missing blueprint",
+			wat:    `(module (func (export "_start")))`,
+			expect: "exited without writing /blueprint.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := wasmtime.Wat2Wasm(test.wat)
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "main.wasm")
+			require.NoError(t, os.WriteFile(path, data, 0o644))
+
+			var logs bytes.Buffer
+			in := &interpreter.Interpreter{
+				Logger:   slog.New(slog.NewTextHandler(&logs, nil)),
+				CacheDir: t.TempDir(),
+			}
+			_, err = in.InterpretBlueprint(external_ast.BlueprintSource{
+				LocalFile: external_ast.BlueprintSourceLocalFile{Path: path},
+			}, nil)
+			require.EqualError(t, err, "blueprint "+path+" "+test.expect)
+			require.Contains(t, logs.String(), test.logged)
+		})
+	}
+}
+
+// buildWasm builds the blueprint program in the package pkg into a temporary directory and returns its path.
+func buildWasm(t *testing.T, pkg string) string {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is needed to build the blueprint program")
+	}
+
+	path := filepath.Join(t.TempDir(), "main.wasm")
+	build := exec.Command(goBin, "build", "-o", path, pkg)
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return path
+}
+
+func TestInterpreter_WasmHostFunctions(t *testing.T) {
+	path := buildWasm(t, "./testdata/host")
+	dir := filepath.Dir(path)
+
+	data, err := os.ReadFile("testdata/host/data.txt")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.txt"), data, 0o644))
+	require.NoError(t, os.Symlink("data.txt", filepath.Join(dir, "alias.txt")))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "dir"), 0o755))
+
+	// A symlink out of the directory of the program.
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("hunter2"), 0o644))
+	require.NoError(t, os.Symlink(secret, filepath.Join(dir, "escape.txt")))
+
+	t.Setenv("REGION", "us-east1")
+	t.Setenv("SECRET", "hunter2")
+
+	var logs bytes.Buffer
+	in := &interpreter.Interpreter{
+		Logger:     slog.New(slog.NewTextHandler(&logs, nil)),
+		CacheDir:   t.TempDir(),
+		AllowedEnv: []string{"REGION"},
+	}
+	bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+		LocalFile: external_ast.BlueprintSourceLocalFile{Path: path},
+	}, map[string]any{"name": "my-build"})
+	require.NoError(t, err, logs.String())
+
+	outputs := map[string]string{}
+	for _, stmt := range bp.Stmts {
+		o := stmt.Value.(external_ast.DeclareOutput)
+		outputs[o.Name] = o.Value.Value.(external_ast.StringLiteral).Value
+	}
+	require.Equal(t, map[string]string{
+		"name":   "my-build",
+		"region": "us-east1",
+		"data":   "from a file",
+	}, outputs)
+	require.Contains(t, logs.String(), `level=WARN msg="hello from the blueprint" blueprint=`+path+` region=us-east1`)
+}
+
+func TestInterpreter_WasmExecBuild(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the blueprint program")
+	}
+
+	path := buildWasm(t, "./testdata/execbuild")
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	// The exec source is in a document that the WebAssembly blueprint declares, not in the blueprint itself.
+	nested, err := external_ast.MarshalBlueprint(external_ast.Blueprint{Stmts: []external_ast.Stmt{
+		{Type: "build", Value: external_ast.DeclareBuild{
+			Name:         "host",
+			Exists:       external_ast.Expr{Type: "bool", Value: external_ast.BoolLiteral{Value: true}},
+			Runtimeinput: external_ast.Expr{Type: "map", Value: external_ast.MapCollection{Value: map[string]external_ast.Expr{}}},
+			BlueprintSource: external_ast.BlueprintSource{Exec: external_ast.BlueprintSourceExec{
+				Command: "sh",
+				Args:    []string{"-c", `touch "$0" && echo '{"stmts": []}'`, marker},
+			}},
+		}},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), "nested.json"), nested, 0o644))
+
+	build := external_ast.DeclareBuild{
+		Name:            "Build",
+		Exists:          external_ast.Expr{Type: "bool", Value: external_ast.BoolLiteral{Value: true}},
+		Runtimeinput:    external_ast.Expr{Type: "map", Value: external_ast.MapCollection{Value: map[string]external_ast.Expr{}}},
+		BlueprintSource: external_ast.BlueprintSource{LocalFile: external_ast.BlueprintSourceLocalFile{Path: path}},
+	}
+
+	t.Run("refused by default", func(t *testing.T) {
+		v := external_ast.Validator{BlueprintInterpreter: &interpreter.Interpreter{CacheDir: t.TempDir()}}
+		err := v.Validate("", build)
+		require.ErrorContains(t, err, "blueprint sh runs on the host, but is declared below a WebAssembly blueprint: exec sources there must be allowed with --allow-exec")
+		require.NoFileExists(t, marker)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		v := external_ast.Validator{BlueprintInterpreter: &interpreter.Interpreter{CacheDir: t.TempDir(), AllowExec: true}}
+		require.NoError(t, v.Validate("", build))
+		require.FileExists(t, marker)
+	})
+}
+
+func TestInterpreter_WasmBundle(t *testing.T) {
+	path := buildWasm(t, "./testdata/bundle")
+
+	in := &interpreter.Interpreter{CacheDir: t.TempDir()}
+	bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+		LocalFile: external_ast.BlueprintSourceLocalFile{Path: path, Bundle: "testdata/bundle/data"},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []external_ast.Stmt{
+		{Type: "output", Value: external_ast.DeclareOutput{
+			Name:  "region",
+			Value: external_ast.Expr{Type: "string", Value: external_ast.StringLiteral{Value: "us-east1"}},
+		}},
+	}, bp.Stmts)
+
+	region, err := os.ReadFile("testdata/bundle/data/region.txt")
+	require.NoError(t, err)
+	require.Equal(t, "us-east1", string(region))
+
+	_, err = in.InterpretBlueprint(external_ast.BlueprintSource{
+		LocalFile: external_ast.BlueprintSourceLocalFile{Path: path, Bundle: "testdata/bundle/missing"},
+	}, nil)
+	require.ErrorContains(t, err, "bundling testdata/bundle/missing for blueprint "+path)
+}
+
+func TestInterpreter_WasmBundleChanged(t *testing.T) {
+	path := buildWasm(t, "./testdata/bundle")
+	bundle := t.TempDir()
+
+	in := &interpreter.Interpreter{CacheDir: t.TempDir()}
+
+	// Bundles are copied once per content, so a changed bundle gets a new copy.
+	for _, region := range []string{"us-east1", "europe-west1"} {
+		require.NoError(t, os.WriteFile(filepath.Join(bundle, "region.txt"), []byte(region), 0o644))
+
+		bp, err := in.InterpretBlueprint(external_ast.BlueprintSource{
+			LocalFile: external_ast.BlueprintSourceLocalFile{Path: path, Bundle: bundle},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, region, bp.Stmts[0].Value.(external_ast.DeclareOutput).Value.Value.(external_ast.StringLiteral).Value)
+	}
+
+	require.NoError(t, in.Close())
+}
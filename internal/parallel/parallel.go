@@ -0,0 +1,64 @@
+// Package parallel runs work concurrently with bounded parallelism.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Limiter bounds how many calls to Do run at once. The zero value allows runtime.NumCPU() of them.
+type Limiter struct {
+	// N is how many calls to Do may run at once.
+	N int
+
+	once sync.Once
+	sem  chan struct{}
+}
+
+// Do calls f once fewer than N other calls are running.
+func (l *Limiter) Do(f func()) {
+	l.once.Do(func() {
+		n := l.N
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+
+		l.sem = make(chan struct{}, n)
+	})
+
+	l.sem <- struct{}{}
+	defer func() { <-l.sem }()
+
+	f()
+}
+
+// Map calls f for each of items and returns the results in the same order. Items for which async returns true
+// are handled in their own goroutines, and the others in order by the calling goroutine. If any calls fail, the
+// error of the first failed item is returned.
+func Map[T, R any](items []T, async func(T) bool, f func(T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		if !async(item) {
+			results[i], errs[i] = f(item)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item T) {
+			defer wg.Done()
+			results[i], errs[i] = f(item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
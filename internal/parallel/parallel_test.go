@@ -0,0 +1,59 @@
+package parallel_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alchematik/athanor/internal/parallel"
+)
+
+func TestMap(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6}
+	even := func(i int) bool { return i%2 == 0 }
+
+	out, err := parallel.Map(items, even, func(i int) (string, error) {
+		return fmt.Sprint(i * 10), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"10", "20", "30", "40", "50", "60"}, out)
+
+	_, err = parallel.Map(items, even, func(i int) (string, error) {
+		if i >= 4 {
+			return "", fmt.Errorf("item %d failed", i)
+		}
+
+		return "", nil
+	})
+	require.EqualError(t, err, "item 4 failed")
+}
+
+func TestLimiter(t *testing.T) {
+	l := &parallel.Limiter{N: 2}
+
+	var running, most atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Do(func() {
+				n := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					m := most.Load()
+					if n <= m || most.CompareAndSwap(m, n) {
+						break
+					}
+				}
+			})
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, most.Load(), int32(2))
+}
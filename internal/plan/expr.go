@@ -2,6 +2,8 @@ package plan
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 )
 
 type StmtBuild struct {
@@ -25,6 +27,24 @@ type StmtResource struct {
 	Config     Expr[any]
 }
 
+type StmtData struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	Type       Expr[string]
+	Provider   Expr[Provider]
+	Identifier Expr[any]
+}
+
+type StmtOutput struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	Value Expr[any]
+}
+
 type Expr[T any] interface {
 	Eval(context.Context, *Plan) (Maybe[T], error)
 }
@@ -45,6 +65,39 @@ func ToMaybeType[V any](m Maybe[any]) Maybe[V] {
 	return Maybe[V]{Value: v, Unknown: m.Unknown}
 }
 
+// Plain strips the plan wrappers from a value, including Sensitive. It returns false if any part of the value
+// is unknown.
+func Plain(m Maybe[any]) (any, bool) {
+	v, ok := m.Unwrap()
+	if !ok {
+		return nil, false
+	}
+
+	switch v := v.(type) {
+	case Sensitive:
+		return Plain(Maybe[any]{Value: v.Value})
+	case map[Maybe[string]]Maybe[any]:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			key, ok := k.Unwrap()
+			if !ok {
+				return nil, false
+			}
+
+			plain, ok := Plain(val)
+			if !ok {
+				return nil, false
+			}
+
+			out[key] = plain
+		}
+
+		return out, true
+	default:
+		return v, true
+	}
+}
+
 type ExprAny[T any] struct {
 	Value Expr[T]
 }
@@ -110,3 +163,100 @@ func (e ExprProvider) Eval(ctx context.Context, p *Plan) (Maybe[Provider], error
 	}
 	return Maybe[Provider]{Value: out}, nil
 }
+
+// Sensitive wraps a value that must never be displayed.
+type Sensitive struct {
+	Value any
+}
+
+func (s Sensitive) String() string {
+	return "(sensitive)"
+}
+
+func (s Sensitive) GoString() string {
+	return s.String()
+}
+
+func (s Sensitive) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+type ExprSensitive struct {
+	Value Expr[any]
+}
+
+func (e ExprSensitive) Eval(ctx context.Context, p *Plan) (Maybe[any], error) {
+	out, err := e.Value.Eval(ctx, p)
+	if err != nil {
+		return Maybe[any]{}, err
+	}
+
+	return Maybe[any]{Value: Sensitive{Value: out.Value}, Unknown: out.Unknown}, nil
+}
+
+// ExprGetOutput is the value of the output with ID. It is unknown until everything the output depends on is known.
+type ExprGetOutput struct {
+	ID string
+}
+
+func (e ExprGetOutput) Eval(_ context.Context, p *Plan) (Maybe[any], error) {
+	o, ok := p.Output(e.ID)
+	if !ok {
+		return Maybe[any]{}, fmt.Errorf("output not in plan: %s", e.ID)
+	}
+
+	if err := o.GetEvalState().Error; err != nil {
+		return Maybe[any]{}, fmt.Errorf("output %s: %w", e.ID, err)
+	}
+
+	return o.Value(), nil
+}
+
+// ExprGetResource is the resource with ID as a map of its identifier, config and attributes. Attributes are
+// set by the provider, so they are unknown until the resource is reconciled.
+type ExprGetResource struct {
+	ID string
+}
+
+func (e ExprGetResource) Eval(_ context.Context, p *Plan) (Maybe[any], error) {
+	r, ok := p.Resource(e.ID)
+	if !ok {
+		return Maybe[any]{}, fmt.Errorf("resource not in plan: %s", e.ID)
+	}
+
+	if err := r.GetEvalState().Error; err != nil {
+		return Maybe[any]{}, fmt.Errorf("resource %s: %w", e.ID, err)
+	}
+
+	out := map[Maybe[string]]Maybe[any]{
+		{Value: "identifier"}: r.Identifier(),
+		{Value: "config"}:     r.Config(),
+		{Value: "attributes"}: {Unknown: true},
+	}
+
+	return Maybe[any]{Value: out}, nil
+}
+
+// ExprGetData is the data lookup with ID as a map of its identifier, config and attributes.
+type ExprGetData struct {
+	ID string
+}
+
+func (e ExprGetData) Eval(_ context.Context, p *Plan) (Maybe[any], error) {
+	d, ok := p.DataLookup(e.ID)
+	if !ok {
+		return Maybe[any]{}, fmt.Errorf("data not in plan: %s", e.ID)
+	}
+
+	if err := d.GetEvalState().Error; err != nil {
+		return Maybe[any]{}, fmt.Errorf("data %s: %w", e.ID, err)
+	}
+
+	out := map[Maybe[string]]Maybe[any]{
+		{Value: "identifier"}: d.Identifier(),
+		{Value: "config"}:     d.Config(),
+		{Value: "attributes"}: d.Attributes(),
+	}
+
+	return Maybe[any]{Value: out}, nil
+}
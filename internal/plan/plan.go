@@ -9,6 +9,8 @@ type Plan struct {
 
 	Resources map[string]*ResourcePlan
 	Builds    map[string]*BuildPlan
+	Outputs   map[string]*OutputPlan
+	Data      map[string]*DataPlan
 }
 
 func (p *Plan) Resource(id string) (*ResourcePlan, bool) {
@@ -27,6 +29,50 @@ func (p *Plan) Build(id string) (*BuildPlan, bool) {
 	return b, ok
 }
 
+func (p *Plan) Output(id string) (*OutputPlan, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	o, ok := p.Outputs[id]
+	return o, ok
+}
+
+func (p *Plan) DataLookup(id string) (*DataPlan, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	d, ok := p.Data[id]
+	return d, ok
+}
+
+func (p *Plan) SetResource(id string, resource *ResourcePlan) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.Resources[id] = resource
+}
+
+func (p *Plan) SetBuild(id string, build *BuildPlan) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.Builds[id] = build
+}
+
+func (p *Plan) SetOutput(id string, output *OutputPlan) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.Outputs[id] = output
+}
+
+func (p *Plan) SetData(id string, data *DataPlan) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.Data[id] = data
+}
+
 func NewResourcePlan(name string) *ResourcePlan {
 	return &ResourcePlan{name: name}
 }
@@ -156,19 +202,28 @@ func (r *ResourcePlan) ToEvaluating() {
 	r.evalState.State = "evaluating"
 }
 
-func NewBuildPlan(name string) *BuildPlan {
-	return &BuildPlan{name: name}
+func NewBuildPlan(name, source string) *BuildPlan {
+	return &BuildPlan{name: name, source: source}
 }
 
 type BuildPlan struct {
 	sync.Mutex
 
 	name      string
+	source    string
 	exists    Maybe[bool]
 	evalState EvalState
 	error     error
 }
 
+// GetSource is where the blueprint of the build is read from, with its path resolved.
+func (b *BuildPlan) GetSource() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.source
+}
+
 func (b *BuildPlan) GetName() string {
 	b.Lock()
 	defer b.Unlock()
@@ -219,6 +274,192 @@ func (b *BuildPlan) ToEvaluating() {
 	b.evalState.State = "evaluating"
 }
 
+func NewDataPlan(name string) *DataPlan {
+	return &DataPlan{name: name}
+}
+
+// DataPlan is an object that is read during planning. Its values are unknown if its identifier is.
+type DataPlan struct {
+	sync.Mutex
+
+	name      string
+	evalState EvalState
+
+	resourceType Maybe[string]
+	provider     Maybe[Provider]
+	identifier   Maybe[any]
+	config       Maybe[any]
+	attributes   Maybe[any]
+}
+
+func (d *DataPlan) GetName() string {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.name
+}
+
+func (d *DataPlan) Type() Maybe[string] {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.resourceType
+}
+
+func (d *DataPlan) SetType(t Maybe[string]) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.resourceType = t
+}
+
+func (d *DataPlan) Provider() Maybe[Provider] {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.provider
+}
+
+func (d *DataPlan) SetProvider(provider Maybe[Provider]) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.provider = provider
+}
+
+func (d *DataPlan) Identifier() Maybe[any] {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.identifier
+}
+
+func (d *DataPlan) SetIdentifier(id Maybe[any]) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.identifier = id
+}
+
+func (d *DataPlan) Config() Maybe[any] {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.config
+}
+
+func (d *DataPlan) SetConfig(config Maybe[any]) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.config = config
+}
+
+func (d *DataPlan) Attributes() Maybe[any] {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.attributes
+}
+
+func (d *DataPlan) SetAttributes(attrs Maybe[any]) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.attributes = attrs
+}
+
+func (d *DataPlan) GetEvalState() EvalState {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.evalState
+}
+
+func (d *DataPlan) ToError(err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "error"
+	d.evalState.Error = err
+}
+
+func (d *DataPlan) ToDone() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "done"
+}
+
+func (d *DataPlan) ToEvaluating() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "evaluating"
+}
+
+func NewOutputPlan(name string) *OutputPlan {
+	return &OutputPlan{name: name}
+}
+
+type OutputPlan struct {
+	sync.Mutex
+
+	name      string
+	evalState EvalState
+	value     Maybe[any]
+}
+
+func (o *OutputPlan) GetName() string {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.name
+}
+
+func (o *OutputPlan) Value() Maybe[any] {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.value
+}
+
+func (o *OutputPlan) SetValue(value Maybe[any]) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.value = value
+}
+
+func (o *OutputPlan) GetEvalState() EvalState {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.evalState
+}
+
+func (o *OutputPlan) ToError(err error) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "error"
+	o.evalState.Error = err
+}
+
+func (o *OutputPlan) ToDone() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "done"
+}
+
+func (o *OutputPlan) ToEvaluating() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "evaluating"
+}
+
 type Resource struct {
 	Type       Maybe[string]
 	Provider   Maybe[Provider]
@@ -1,6 +1,12 @@
 package scope
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/alchematik/athanor/internal/dag"
 	"github.com/alchematik/athanor/internal/set"
 )
@@ -11,10 +17,16 @@ func NewScope() *Scope {
 		dag:        dag.NewGraph(),
 		resources:  map[string]*set.Set[string]{},
 		builds:     map[string]*set.Set[string]{},
+		outputs:    map[string]*set.Set[string]{},
+		data:       map[string]*set.Set[string]{},
+		moved:      map[string]string{},
 	}
 }
 
+// Scope is safe for concurrent use, so that sibling builds can be converted in parallel.
 type Scope struct {
+	sync.Mutex
+
 	components map[string]any
 	dag        *dag.Graph
 
@@ -23,9 +35,90 @@ type Scope struct {
 
 	// builds is a map of build ID to child builds.
 	builds map[string]*set.Set[string]
+
+	// outputs is a map of build ID to the outputs it declares.
+	outputs map[string]*set.Set[string]
+
+	// data is a map of build ID to child data lookups.
+	data map[string]*set.Set[string]
+
+	// dependsOn are the explicit dependencies between components. They are resolved when the iterator is
+	// created, since a component can depend on one that is declared after it.
+	dependsOn []dependency
+
+	// references are the outputs, resources and data lookups that expressions refer to. They are checked when the
+	// iterator is created, since an expression can refer to a component that is declared after it.
+	references []reference
+
+	// moved maps the ID that a resource or build was moved to, to the ID it had before.
+	moved map[string]string
+
+	// errs are the problems found while components were declared, such as edges that couldn't be added to the
+	// graph. They are returned when the iterator is created, since components are declared without returning
+	// errors.
+	errs []error
+}
+
+type dependency struct {
+	// owner is the component that waits.
+	owner   string
+	id      string
+	address string
+}
+
+type reference struct {
+	// owner is the component whose expression refers to id.
+	owner string
+	kind  string
+	id    string
+	name  string
+}
+
+// OutputID is the ID of the output name declared by a build. Outputs are kept apart from resources and builds,
+// which can't have '#' in their names.
+func OutputID(buildID, name string) string {
+	return fmt.Sprintf("%s#%s", buildID, name)
+}
+
+// Owner is the component that an expression belongs to.
+type Owner struct {
+	Scope *Scope
+
+	// BuildID is the build that declares the component. References made by the expression are resolved
+	// relative to it.
+	BuildID string
+
+	// ID is the component itself.
+	ID string
+}
+
+// Reference makes the component wait for the output, resource or data lookup id, which it refers to as name.
+// kind is "output", "resource" or "data".
+func (o Owner) Reference(kind, name, id string) {
+	o.Scope.Lock()
+	defer o.Scope.Unlock()
+
+	o.Scope.references = append(o.Scope.references, reference{owner: o.ID, kind: kind, id: id, name: name})
+}
+
+// DependOnAddress makes the component wait for the resource or build at address, which is relative to the build
+// that declares the component. Depending on a repeated resource or build waits for all of its instances, and
+// depending on a build waits for everything declared in it.
+func (o Owner) DependOnAddress(address string) {
+	o.Scope.Lock()
+	defer o.Scope.Unlock()
+
+	o.Scope.dependsOn = append(o.Scope.dependsOn, dependency{
+		owner:   o.ID,
+		id:      o.BuildID + "." + address,
+		address: address,
+	})
 }
 
 func (s *Scope) SetBuild(parent, id string, e any) {
+	s.Lock()
+	defer s.Unlock()
+
 	s.components[id] = e
 
 	existing, ok := s.builds[parent]
@@ -41,10 +134,13 @@ func (s *Scope) SetBuild(parent, id string, e any) {
 		return
 	}
 
-	s.dag.AddEdge(parent, id)
+	s.addEdge(parent, id)
 }
 
 func (s *Scope) SetResource(parent, id string, e any) {
+	s.Lock()
+	defer s.Unlock()
+
 	s.components[id] = e
 
 	existing, ok := s.resources[parent]
@@ -60,19 +156,255 @@ func (s *Scope) SetResource(parent, id string, e any) {
 		return
 	}
 
-	s.dag.AddEdge(parent, id)
+	s.addEdge(parent, id)
+}
+
+func (s *Scope) SetOutput(parent, id string, e any) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.components[id] = e
+
+	existing, ok := s.outputs[parent]
+	if !ok {
+		existing = set.NewSet[string]()
+		s.outputs[parent] = existing
+	}
+
+	existing.Add(id)
+
+	s.addEdge(parent, id)
+}
+
+func (s *Scope) SetData(parent, id string, e any) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.components[id] = e
+
+	existing, ok := s.data[parent]
+	if !ok {
+		existing = set.NewSet[string]()
+		s.data[parent] = existing
+	}
+
+	existing.Add(id)
+
+	s.addEdge(parent, id)
+}
+
+// Moved records that a resource or build was at From before it was at To.
+type Moved struct {
+	BuildID string
+	From    string
+	To      string
+}
+
+// Move records that the resource or build at the address from, in the build buildID, is now at the address to.
+// Moves are not evaluated.
+func (s *Scope) Move(buildID, from, to string) Moved {
+	s.Lock()
+	defer s.Unlock()
+
+	m := Moved{
+		BuildID: buildID,
+		From:    fmt.Sprintf("%s.%s", buildID, from),
+		To:      fmt.Sprintf("%s.%s", buildID, to),
+	}
+	if existing, ok := s.moved[m.To]; ok && existing != m.From {
+		s.errs = append(s.errs, fmt.Errorf("%s: moved to %s, which %s is already moved to", m.From, m.To, existing))
+		return m
+	}
+	s.moved[m.To] = m.From
+
+	return m
+}
+
+// MovedFrom returns the ID that the resource or build id had before it was moved.
+func (s *Scope) MovedFrom(id string) (string, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	from, ok := s.moved[id]
+	return from, ok
+}
+
+// AddDependency makes the component "to" wait until the component "from" has been evaluated.
+func (s *Scope) AddDependency(from, to string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.addEdge(from, to)
 }
 
 func (s *Scope) Component(id string) (any, bool) {
+	s.Lock()
+	defer s.Unlock()
+
 	comp, ok := s.components[id]
 	return comp, ok
 }
 
-func (s *Scope) NewIterator() *dag.Iterator {
-	return dag.InitIterator(s.dag)
+// NewIterator resolves the explicit dependencies between components, checks where they were moved to and that
+// the dependencies have no cycles, and returns an iterator over all of them.
+func (s *Scope) NewIterator() (*dag.Iterator, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	// Builds are converted in parallel, so dependencies are sorted to report errors in the same order every time.
+	sort.Slice(s.dependsOn, func(i, j int) bool {
+		if s.dependsOn[i].owner != s.dependsOn[j].owner {
+			return s.dependsOn[i].owner < s.dependsOn[j].owner
+		}
+
+		return s.dependsOn[i].address < s.dependsOn[j].address
+	})
+
+	errs := append([]error{}, s.errs...)
+	for _, dep := range s.dependsOn {
+		if dep.id == dep.owner {
+			errs = append(errs, fmt.Errorf("%s: can't depend on itself", dep.owner))
+			continue
+		}
+
+		ids := s.instances(dep.id)
+		if len(ids) == 0 {
+			errs = append(errs, fmt.Errorf("%s: depends on %q, which is not a resource or build", dep.owner, dep.address))
+			continue
+		}
+
+		for _, id := range ids {
+			// Depending on a build waits for everything in it, not only for the build to start.
+			s.addEdge(id, dep.owner)
+			for _, d := range s.descendants(id) {
+				s.addEdge(d, dep.owner)
+			}
+		}
+	}
+
+	sort.Slice(s.references, func(i, j int) bool {
+		if s.references[i].owner != s.references[j].owner {
+			return s.references[i].owner < s.references[j].owner
+		}
+
+		return s.references[i].id < s.references[j].id
+	})
+
+	for i, ref := range s.references {
+		// Diffs convert the same expressions for both the plan and the state.
+		if i > 0 && ref == s.references[i-1] {
+			continue
+		}
+
+		if !s.declared(ref.kind, ref.id) {
+			errs = append(errs, fmt.Errorf("%s: references %s %q, which is not declared", ref.owner, ref.kind, ref.name))
+			continue
+		}
+
+		s.addEdge(ref.id, ref.owner)
+	}
+
+	moves := make([]string, 0, len(s.moved))
+	for to := range s.moved {
+		moves = append(moves, to)
+	}
+	sort.Strings(moves)
+
+	for _, to := range moves {
+		from := s.moved[to]
+		if _, ok := s.components[from]; ok {
+			errs = append(errs, fmt.Errorf("%s: moved to %s but still declared", from, to))
+		}
+		if ids := s.instances(to); len(ids) != 1 || ids[0] != to {
+			errs = append(errs, fmt.Errorf("%s: moved to %s, which is not a resource or build", from, to))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// A cycle would never be iterated over, leaving its components waiting forever.
+	if err := s.dag.CheckCycles(); err != nil {
+		return nil, err
+	}
+
+	s.dependsOn = nil
+	s.references = nil
+	return dag.InitIterator(s.dag), nil
+}
+
+func (s *Scope) addEdge(from, to string) {
+	if err := s.dag.AddEdge(from, to); err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s -> %s: %w", from, to, err))
+	}
+}
+
+// descendants returns the resources, builds, data lookups and outputs declared in the build id and in the builds
+// it declares, all the way down.
+func (s *Scope) descendants(id string) []string {
+	var ids []string
+	for _, children := range []map[string]*set.Set[string]{s.resources, s.data, s.outputs} {
+		if c, ok := children[id]; ok {
+			ids = append(ids, c.Values()...)
+		}
+	}
+
+	if builds, ok := s.builds[id]; ok {
+		for _, b := range builds.Values() {
+			ids = append(ids, b)
+			ids = append(ids, s.descendants(b)...)
+		}
+	}
+
+	return ids
+}
+
+// declared reports whether id is an output, resource or data lookup, depending on kind.
+func (s *Scope) declared(kind, id string) bool {
+	children := map[string]map[string]*set.Set[string]{
+		"output":   s.outputs,
+		"resource": s.resources,
+		"data":     s.data,
+	}[kind]
+
+	for _, c := range children {
+		for _, child := range c.Values() {
+			if child == id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// instances returns id if it is a resource or build, or else the instances of the repeated resource or build id.
+func (s *Scope) instances(id string) []string {
+	var ids []string
+	for _, children := range []map[string]*set.Set[string]{s.resources, s.builds} {
+		for _, c := range children {
+			for _, child := range c.Values() {
+				if child == id {
+					return []string{id}
+				}
+
+				// Instances of instances are declared further down, under their own build.
+				key, ok := strings.CutPrefix(child, id+"[")
+				if ok && strings.HasSuffix(key, "]") && !strings.Contains(key, "[") {
+					ids = append(ids, child)
+				}
+			}
+		}
+	}
+
+	return ids
 }
 
 func (s *Scope) Resources(buildID string) []string {
+	s.Lock()
+	defer s.Unlock()
+
 	if resources, ok := s.resources[buildID]; ok {
 		return resources.Values()
 	}
@@ -81,9 +413,34 @@ func (s *Scope) Resources(buildID string) []string {
 }
 
 func (s *Scope) Builds(buildID string) []string {
+	s.Lock()
+	defer s.Unlock()
+
 	if builds, ok := s.builds[buildID]; ok {
 		return builds.Values()
 	}
 
 	return nil
 }
+
+func (s *Scope) Outputs(buildID string) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	if outputs, ok := s.outputs[buildID]; ok {
+		return outputs.Values()
+	}
+
+	return nil
+}
+
+func (s *Scope) Data(buildID string) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	if data, ok := s.data[buildID]; ok {
+		return data.Values()
+	}
+
+	return nil
+}
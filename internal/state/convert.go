@@ -1,57 +1,94 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 
 	external "github.com/alchematik/athanor/ast"
+	"github.com/alchematik/athanor/internal/parallel"
 	"github.com/alchematik/athanor/internal/scope"
 )
 
 type Converter struct {
 	BlueprintInterpreter BlueprintInterpreter
+	// Interpreting bounds how many blueprints are interpreted at once while sub-builds are converted in
+	// parallel.
+	Interpreting parallel.Limiter
 }
 
 type BlueprintInterpreter interface {
 	InterpretBlueprint(source external.BlueprintSource, input map[string]any) (external.Blueprint, error)
 }
 
+func isBuild(stmt external.Stmt) bool {
+	_, ok := stmt.Value.(external.DeclareBuild)
+	return ok
+}
+
 func (c *Converter) ConvertStmt(s *State, sc *scope.Scope, parentID string, stmt external.Stmt) (any, error) {
 	switch stmt := stmt.Value.(type) {
 	case external.DeclareBuild:
 		return c.ConvertBuildStmt(s, sc, parentID, stmt)
 	case external.DeclareResource:
 		return c.ConvertResourceStmt(s, sc, parentID, stmt)
+	case external.DeclareData:
+		return c.ConvertDataStmt(s, sc, parentID, stmt)
+	case external.DeclareOutput:
+		return c.ConvertOutputStmt(s, sc, parentID, stmt)
+	case external.DeclareMoved:
+		return sc.Move(parentID, stmt.From, stmt.To), nil
 	default:
 		return nil, fmt.Errorf("unsupported statement type: %T", stmt)
 	}
 }
 
 func (c *Converter) ConvertBuildStmt(s *State, sc *scope.Scope, parentID string, build external.DeclareBuild) (StmtBuild, error) {
-	blueprint, err := c.BlueprintInterpreter.InterpretBlueprint(build.BlueprintSource, build.Input)
+	var blueprint external.Blueprint
+	var err error
+	c.Interpreting.Do(func() {
+		blueprint, build, err = external.InterpretBuild(c.BlueprintInterpreter, build)
+	})
 	if err != nil {
 		return StmtBuild{}, err
 	}
 
-	runtimeInput, err := c.ConvertMapExpr(build.Name, build.Runtimeinput)
+	buildID := fmt.Sprintf("%s.%s", parentID, build.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: buildID}
+
+	if errs := external.CheckInputs(buildID, build, blueprint); len(errs) > 0 {
+		return StmtBuild{}, errs
+	}
+
+	for _, address := range build.DependsOn {
+		owner.DependOnAddress(address)
+	}
+
+	runtimeInput, err := c.ConvertMapExpr(owner, build.Runtimeinput)
 	if err != nil {
 		return StmtBuild{}, fmt.Errorf("converting runtime input: %s", err)
 	}
 
-	exists, err := c.ConvertBoolExpr(build.Name, build.Exists)
+	exists, err := c.ConvertBoolExpr(owner, build.Exists)
 	if err != nil {
 		return StmtBuild{}, err
 	}
 
-	buildID := fmt.Sprintf("%s.%s", parentID, build.Name)
-
-	var stmts []any
+	var instances []external.Stmt
 	for _, stmt := range blueprint.Stmts {
-		s, err := c.ConvertStmt(s, sc, buildID, stmt)
+		expanded, err := external.Expand(stmt)
 		if err != nil {
-			return StmtBuild{}, err
+			return StmtBuild{}, fmt.Errorf("%s.%s", buildID, err)
 		}
 
-		stmts = append(stmts, s)
+		instances = append(instances, expanded...)
+	}
+
+	// Sub-builds are converted in parallel, since interpreting their blueprints is slow.
+	stmts, err := parallel.Map(instances, isBuild, func(stmt external.Stmt) (any, error) {
+		return c.ConvertStmt(s, sc, buildID, stmt)
+	})
+	if err != nil {
+		return StmtBuild{}, err
 	}
 
 	b := StmtBuild{
@@ -64,7 +101,7 @@ func (c *Converter) ConvertBuildStmt(s *State, sc *scope.Scope, parentID string,
 	}
 
 	sc.SetBuild(parentID, buildID, b)
-	s.Builds[buildID] = NewBuildState(build.Name)
+	s.SetBuild(buildID, NewBuildState(build.Name, build.BlueprintSource.String()))
 
 	return b, nil
 }
@@ -72,22 +109,27 @@ func (c *Converter) ConvertBuildStmt(s *State, sc *scope.Scope, parentID string,
 func (c *Converter) ConvertResourceStmt(s *State, sc *scope.Scope, parentID string, stmt external.DeclareResource) (StmtResource, error) {
 	// TODO: Validate
 
-	t, err := c.ConvertStringExpr(stmt.Name, stmt.Type)
+	resourceID := fmt.Sprintf("%s.%s", parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: resourceID}
+	for _, address := range stmt.DependsOn {
+		owner.DependOnAddress(address)
+	}
+
+	t, err := c.ConvertStringExpr(owner, stmt.Type)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	provider, err := c.ConvertProviderExpr(stmt.Name, stmt.Provider)
+	provider, err := c.ConvertProviderExpr(owner, stmt.Provider)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	id, err := c.ConvertAnyExpr(stmt.Name, stmt.Identifier)
+	id, err := c.ConvertAnyExpr(owner, stmt.Identifier)
 	if err != nil {
 		return StmtResource{}, err
 	}
 
-	resourceID := fmt.Sprintf("%s.%s", parentID, stmt.Name)
 	r := StmtResource{
 		ID:      resourceID,
 		Name:    stmt.Name,
@@ -99,19 +141,74 @@ func (c *Converter) ConvertResourceStmt(s *State, sc *scope.Scope, parentID stri
 	}
 
 	sc.SetResource(parentID, resourceID, r)
-	s.Resources[resourceID] = NewResourceState(stmt.Name)
+	s.SetResource(resourceID, NewResourceState(stmt.Name))
 	return r, nil
 }
 
-func (c *Converter) ConvertProviderExpr(name string, expr external.Expr) (Expr[Provider], error) {
+func (c *Converter) ConvertDataStmt(s *State, sc *scope.Scope, parentID string, stmt external.DeclareData) (StmtData, error) {
+	dataID := fmt.Sprintf("%s.%s", parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: dataID}
+
+	t, err := c.ConvertStringExpr(owner, stmt.Type)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	provider, err := c.ConvertProviderExpr(owner, stmt.Provider)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	id, err := c.ConvertAnyExpr(owner, stmt.Identifier)
+	if err != nil {
+		return StmtData{}, err
+	}
+
+	d := StmtData{
+		ID:      dataID,
+		Name:    stmt.Name,
+		BuildID: parentID,
+
+		Type:       t,
+		Provider:   provider,
+		Identifier: id,
+	}
+
+	sc.SetData(parentID, dataID, d)
+	s.SetData(dataID, NewDataState(stmt.Name))
+	return d, nil
+}
+
+func (c *Converter) ConvertOutputStmt(s *State, sc *scope.Scope, parentID string, stmt external.DeclareOutput) (StmtOutput, error) {
+	outputID := scope.OutputID(parentID, stmt.Name)
+	owner := scope.Owner{Scope: sc, BuildID: parentID, ID: outputID}
+
+	value, err := c.ConvertAnyExpr(owner, stmt.Value)
+	if err != nil {
+		return StmtOutput{}, err
+	}
+
+	o := StmtOutput{
+		ID:      outputID,
+		Name:    stmt.Name,
+		BuildID: parentID,
+		Value:   value,
+	}
+
+	sc.SetOutput(parentID, outputID, o)
+	s.SetOutput(outputID, NewOutputState(stmt.Name))
+	return o, nil
+}
+
+func (c *Converter) ConvertProviderExpr(owner scope.Owner, expr external.Expr) (Expr[Provider], error) {
 	switch value := expr.Value.(type) {
 	case external.Provider:
-		n, err := c.ConvertStringExpr(name, value.Name)
+		n, err := c.ConvertStringExpr(owner, value.Name)
 		if err != nil {
 			return nil, err
 		}
 
-		v, err := c.ConvertStringExpr(name, value.Version)
+		v, err := c.ConvertStringExpr(owner, value.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -121,44 +218,59 @@ func (c *Converter) ConvertProviderExpr(name string, expr external.Expr) (Expr[P
 			Version: v,
 		}, nil
 	default:
-		return nil, fmt.Errorf("invalid provider expr: %T", expr)
+		return nil, fmt.Errorf("invalid provider expr: %T", expr.Value)
 	}
 }
 
-func (c *Converter) ConvertAnyExpr(name string, expr external.Expr) (Expr[any], error) {
+func (c *Converter) ConvertAnyExpr(owner scope.Owner, expr external.Expr) (Expr[any], error) {
 	switch expr.Value.(type) {
 	case external.StringLiteral:
-		expr, err := c.ConvertStringExpr(name, expr)
+		expr, err := c.ConvertStringExpr(owner, expr)
 		if err != nil {
 			return nil, err
 		}
 
 		return ExprAny[string]{Value: expr}, nil
 	case external.BoolLiteral:
-		expr, err := c.ConvertBoolExpr(name, expr)
+		expr, err := c.ConvertBoolExpr(owner, expr)
 		if err != nil {
 			return nil, err
 		}
 
 		return ExprAny[bool]{Value: expr}, nil
 	case external.MapCollection:
-		expr, err := c.ConvertMapExpr(name, expr)
+		expr, err := c.ConvertMapExpr(owner, expr)
 		if err != nil {
 			return nil, err
 		}
 
 		return ExprAny[map[string]any]{Value: expr}, nil
+	case external.Null:
+		return ExprLiteral[any]{Value: nil}, nil
+	case external.Sensitive:
+		expr, err := c.ConvertSensitiveExpr(owner, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return expr, nil
+	case external.GetOutput:
+		return c.ConvertGetOutputExpr(owner, expr)
+	case external.GetResource:
+		return c.ConvertGetResourceExpr(owner, expr)
+	case external.GetData:
+		return c.ConvertGetDataExpr(owner, expr)
 	default:
 		return nil, fmt.Errorf("invalid expr: %T", expr.Value)
 	}
 }
 
-func (c *Converter) ConvertMapExpr(name string, expr external.Expr) (ExprMap, error) {
+func (c *Converter) ConvertMapExpr(owner scope.Owner, expr external.Expr) (ExprMap, error) {
 	switch value := expr.Value.(type) {
 	case external.MapCollection:
 		m := ExprMap{}
 		for k, v := range value.Value {
-			val, err := c.ConvertAnyExpr(name, v)
+			val, err := c.ConvertAnyExpr(owner, v)
 			if err != nil {
 				return nil, err
 			}
@@ -167,24 +279,78 @@ func (c *Converter) ConvertMapExpr(name string, expr external.Expr) (ExprMap, er
 		}
 		return m, nil
 	default:
-		return nil, fmt.Errorf("%s: invalid map expr: %T", name, expr)
+		return nil, fmt.Errorf("%s: invalid map expr: %T", owner.ID, expr.Value)
 	}
 }
 
-func (c *Converter) ConvertBoolExpr(name string, expr external.Expr) (Expr[bool], error) {
+func (c *Converter) ConvertBoolExpr(owner scope.Owner, expr external.Expr) (Expr[bool], error) {
 	switch value := expr.Value.(type) {
 	case external.BoolLiteral:
 		return ExprLiteral[bool]{Value: value.Value}, nil
 	default:
-		return nil, fmt.Errorf("invalid bool expr: %T", expr)
+		return nil, fmt.Errorf("invalid bool expr: %T", expr.Value)
 	}
 }
 
-func (c *Converter) ConvertStringExpr(name string, expr external.Expr) (Expr[string], error) {
+func (c *Converter) ConvertStringExpr(owner scope.Owner, expr external.Expr) (Expr[string], error) {
 	switch value := expr.Value.(type) {
 	case external.StringLiteral:
 		return ExprLiteral[string]{Value: value.Value}, nil
 	default:
-		return nil, fmt.Errorf("invalid string expr: %T", expr)
+		return nil, fmt.Errorf("invalid string expr: %T", expr.Value)
+	}
+}
+
+func (c *Converter) ConvertSensitiveExpr(owner scope.Owner, expr external.Expr) (ExprSensitive, error) {
+	switch value := expr.Value.(type) {
+	case external.Sensitive:
+		inner, err := c.ConvertAnyExpr(owner, value.Value)
+		if err != nil {
+			return ExprSensitive{}, err
+		}
+
+		return ExprSensitive{Value: inner}, nil
+	default:
+		return ExprSensitive{}, fmt.Errorf("invalid sensitive expr: %T", expr.Value)
+	}
+}
+
+func (c *Converter) ConvertGetOutputExpr(owner scope.Owner, expr external.Expr) (ExprGetOutput, error) {
+	switch value := expr.Value.(type) {
+	case external.GetOutput:
+		id := scope.OutputID(fmt.Sprintf("%s.%s", owner.BuildID, value.Build), value.Name)
+		owner.Reference("output", value.Build+"."+value.Name, id)
+
+		return ExprGetOutput{ID: id}, nil
+	default:
+		return ExprGetOutput{}, fmt.Errorf("invalid get output expr: %T", expr.Value)
+	}
+}
+
+func (c *Converter) ConvertGetResourceExpr(owner scope.Owner, expr external.Expr) (ExprGetResource, error) {
+	switch value := expr.Value.(type) {
+	case external.GetResource:
+		if !value.From.IsEmpty() {
+			return ExprGetResource{}, errors.New("get resource: from is not supported, use an output instead")
+		}
+
+		id := fmt.Sprintf("%s.%s", owner.BuildID, value.Name)
+		owner.Reference("resource", value.Name, id)
+
+		return ExprGetResource{ID: id}, nil
+	default:
+		return ExprGetResource{}, fmt.Errorf("invalid get resource expr: %T", expr.Value)
+	}
+}
+
+func (c *Converter) ConvertGetDataExpr(owner scope.Owner, expr external.Expr) (ExprGetData, error) {
+	switch value := expr.Value.(type) {
+	case external.GetData:
+		id := fmt.Sprintf("%s.%s", owner.BuildID, value.Name)
+		owner.Reference("data", value.Name, id)
+
+		return ExprGetData{ID: id}, nil
+	default:
+		return ExprGetData{}, fmt.Errorf("invalid get data expr: %T", expr.Value)
 	}
 }
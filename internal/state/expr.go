@@ -2,6 +2,8 @@ package state
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 )
 
 type StmtBuild struct {
@@ -23,6 +25,24 @@ type StmtResource struct {
 	Identifier Expr[any]
 }
 
+type StmtData struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	Type       Expr[string]
+	Provider   Expr[Provider]
+	Identifier Expr[any]
+}
+
+type StmtOutput struct {
+	ID      string
+	Name    string
+	BuildID string
+
+	Value Expr[any]
+}
+
 type Expr[T any] interface {
 	Eval(context.Context, *State) (T, error)
 }
@@ -103,3 +123,94 @@ func (e ExprProvider) Eval(ctx context.Context, s *State) (Provider, error) {
 		Version: version,
 	}, nil
 }
+
+// Sensitive wraps a value that must never be displayed.
+type Sensitive struct {
+	Value any
+}
+
+func (s Sensitive) String() string {
+	return "(sensitive)"
+}
+
+func (s Sensitive) GoString() string {
+	return s.String()
+}
+
+func (s Sensitive) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+type ExprSensitive struct {
+	Value Expr[any]
+}
+
+func (e ExprSensitive) Eval(ctx context.Context, s *State) (any, error) {
+	out, err := e.Value.Eval(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return Sensitive{Value: out}, nil
+}
+
+type ExprGetOutput struct {
+	ID string
+}
+
+func (e ExprGetOutput) Eval(_ context.Context, s *State) (any, error) {
+	o, ok := s.Output(e.ID)
+	if !ok {
+		return nil, fmt.Errorf("output not in state: %s", e.ID)
+	}
+
+	if err := o.GetEvalState().Error; err != nil {
+		return nil, fmt.Errorf("output %s: %w", e.ID, err)
+	}
+
+	return o.Value(), nil
+}
+
+// ExprGetResource is the resource with ID as a map of its identifier, config and attributes.
+type ExprGetResource struct {
+	ID string
+}
+
+func (e ExprGetResource) Eval(_ context.Context, s *State) (any, error) {
+	r, ok := s.Resource(e.ID)
+	if !ok {
+		return nil, fmt.Errorf("resource not in state: %s", e.ID)
+	}
+
+	if err := r.GetEvalState().Error; err != nil {
+		return nil, fmt.Errorf("resource %s: %w", e.ID, err)
+	}
+
+	return map[string]any{
+		"identifier": r.Identifier(),
+		"config":     r.Config(),
+		"attributes": r.Attributes(),
+	}, nil
+}
+
+// ExprGetData is the data lookup with ID as a map of its identifier, config and attributes.
+type ExprGetData struct {
+	ID string
+}
+
+func (e ExprGetData) Eval(_ context.Context, s *State) (any, error) {
+	d, ok := s.DataLookup(e.ID)
+	if !ok {
+		return nil, fmt.Errorf("data not in state: %s", e.ID)
+	}
+
+	if err := d.GetEvalState().Error; err != nil {
+		return nil, fmt.Errorf("data %s: %w", e.ID, err)
+	}
+
+	return map[string]any{
+		"identifier": d.Identifier(),
+		"config":     d.Config(),
+		"attributes": d.Attributes(),
+	}, nil
+}
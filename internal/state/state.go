@@ -9,6 +9,8 @@ type State struct {
 
 	Resources map[string]*ResourceState
 	Builds    map[string]*BuildState
+	Outputs   map[string]*OutputState
+	Data      map[string]*DataState
 }
 
 func (s *State) Resource(id string) (*ResourceState, bool) {
@@ -27,6 +29,50 @@ func (s *State) Build(id string) (*BuildState, bool) {
 	return b, ok
 }
 
+func (s *State) Output(id string) (*OutputState, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	o, ok := s.Outputs[id]
+	return o, ok
+}
+
+func (s *State) DataLookup(id string) (*DataState, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	d, ok := s.Data[id]
+	return d, ok
+}
+
+func (s *State) SetResource(id string, resource *ResourceState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Resources[id] = resource
+}
+
+func (s *State) SetBuild(id string, build *BuildState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Builds[id] = build
+}
+
+func (s *State) SetOutput(id string, output *OutputState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Outputs[id] = output
+}
+
+func (s *State) SetData(id string, data *DataState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Data[id] = data
+}
+
 type EvalState struct {
 	State string
 	Error error
@@ -169,19 +215,28 @@ func (r *ResourceState) ToEvaluating() {
 	r.evalState.State = "evaluating"
 }
 
-func NewBuildState(name string) *BuildState {
-	return &BuildState{name: name}
+func NewBuildState(name, source string) *BuildState {
+	return &BuildState{name: name, source: source}
 }
 
 type BuildState struct {
 	sync.Mutex
 
 	name      string
+	source    string
 	exists    bool
 	evalState EvalState
 	error     error
 }
 
+// GetSource is where the blueprint of the build is read from, with its path resolved.
+func (b *BuildState) GetSource() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.source
+}
+
 func (b *BuildState) GetName() string {
 	b.Lock()
 	defer b.Unlock()
@@ -231,3 +286,187 @@ func (b *BuildState) ToEvaluating() {
 
 	b.evalState.State = "evaluating"
 }
+
+func NewOutputState(name string) *OutputState {
+	return &OutputState{name: name}
+}
+
+type OutputState struct {
+	sync.Mutex
+
+	name      string
+	evalState EvalState
+	value     any
+}
+
+func (o *OutputState) GetName() string {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.name
+}
+
+func (o *OutputState) Value() any {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.value
+}
+
+func (o *OutputState) SetValue(value any) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.value = value
+}
+
+func (o *OutputState) GetEvalState() EvalState {
+	o.Lock()
+	defer o.Unlock()
+
+	return o.evalState
+}
+
+func (o *OutputState) ToError(err error) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "error"
+	o.evalState.Error = err
+}
+
+func (o *OutputState) ToDone() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "done"
+}
+
+func (o *OutputState) ToEvaluating() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.evalState.State = "evaluating"
+}
+
+func NewDataState(name string) *DataState {
+	return &DataState{name: name}
+}
+
+type DataState struct {
+	sync.Mutex
+
+	name         string
+	evalState    EvalState
+	resourceType string
+	provider     Provider
+	identifier   any
+	config       any
+	attributes   any
+}
+
+func (d *DataState) GetName() string {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.name
+}
+
+func (d *DataState) Type() string {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.resourceType
+}
+
+func (d *DataState) SetType(t string) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.resourceType = t
+}
+
+func (d *DataState) Provider() Provider {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.provider
+}
+
+func (d *DataState) SetProvider(p Provider) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.provider = p
+}
+
+func (d *DataState) Identifier() any {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.identifier
+}
+
+func (d *DataState) SetIdentifier(id any) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.identifier = id
+}
+
+func (d *DataState) Config() any {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.config
+}
+
+func (d *DataState) SetConfig(config any) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.config = config
+}
+
+func (d *DataState) Attributes() any {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.attributes
+}
+
+func (d *DataState) SetAttributes(attrs any) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.attributes = attrs
+}
+
+func (d *DataState) GetEvalState() EvalState {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.evalState
+}
+
+func (d *DataState) ToError(err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "error"
+	d.evalState.Error = err
+}
+
+func (d *DataState) ToDone() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "done"
+}
+
+func (d *DataState) ToEvaluating() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.evalState.State = "evaluating"
+}
@@ -13,6 +13,22 @@ type GetResourceRequest struct {
 
 type GetResourceResponse struct {
 	Resource Resource
+	Schema   Schema
+
+	// NotFound is set when no object matches the identifier.
+	NotFound bool
+}
+
+// Schema describes how the attributes of a resource should be treated.
+type Schema struct {
+	Attributes map[string]AttributeSchema
+}
+
+type AttributeSchema struct {
+	// Sensitive values are never displayed.
+	Sensitive bool
+	// RequiresReplace attributes can't be changed in place. Changing them replaces the object.
+	RequiresReplace bool
 }
 
 type Resource struct {